@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const usageAmendForce = "Amend even if the last commit has already been pushed"
+
+var amendCmd = &cobra.Command{
+	Use:   "amend",
+	Short: "🩹 Give your last commit the message it deserved",
+	Long: `🩹 Kommit Amend - Because "asdf" is not a coping mechanism!
+
+This command revisits your last commit, diagnoses its changes (plus anything
+you've staged since) and suggests the message it should have had all along,
+before amending it with ` + "`git commit --amend`" + `.
+
+Rewriting history you've already shared is a trust issue, so kommit refuses
+to amend a commit that has been pushed unless you insist with --force.`,
+	Run: runAmend,
+}
+
+func runAmend(cmd *cobra.Command, args []string) {
+	if !utils.RevExists("HEAD") {
+		fmt.Printf("%s: There's no commit to revisit yet.\n", getErrorPrefix(AmendCmd))
+		fmt.Println("(Run `git kommit` to make your first commitment!)")
+		exit(1)
+	}
+
+	remotes, err := utils.GetRemoteBranchesContaining("HEAD")
+	if err != nil && Verbose {
+		log.Printf("Error checking remote branches: %v", err)
+	}
+	if len(remotes) > 0 {
+		if !AmendForce {
+			fmt.Printf("%s: Your last commit has already been shared with %s.\n", getErrorPrefix(AmendCmd), strings.Join(remotes, ", "))
+			fmt.Println("(Rewriting it means a force push. Pass --force if you really mean it.)")
+			exit(1)
+		}
+		fmt.Printf("⚠️  Your last commit has already been shared with %s. You'll need to force push.\n", strings.Join(remotes, ", "))
+	}
+
+	// HEAD's own changes plus anything staged on top of it
+	diff, err := utils.ExecGit("diff", "--cached", utils.ParentOrEmptyTree("HEAD"))
+	if err != nil || diff == "" {
+		fmt.Printf("%s: Your last commit didn't change anything to talk about.\n", getErrorPrefix(AmendCmd))
+		if Verbose && err != nil {
+			log.Printf("Error getting diff: %v", err)
+		}
+		exit(1)
+	}
+
+	config := loadConfig(AmendCmd)
+
+	result := generateCommitMessage(AmendCmd, config, diff, "amend")
+	result.Message = reviewBreakingChange(diff, result.Message)
+
+	commitMessage := withSignature(config, result)
+	violations := lint.Lint(result.Message, configRules(config))
+
+	option := reviewCommitMessage(commitMessage, violations)
+	switch option {
+	case ui.CommitOptionProceed, ui.CommitOptionEdit:
+		commitWithMessage(AmendCmd, option, commitMessage, true)
+	case ui.CommitOptionRerun:
+		runAmend(cmd, args)
+	case ui.CommitOptionExit:
+		fmt.Println("🧐 Your last commit keeps its old message. Call if your commitment issues return!")
+		exit(0)
+	}
+}
+
+var AmendForce bool
+
+func init() {
+	amendCmd.Flags().BoolVarP(&AmendForce, "force", "f", false, usageAmendForce)
+	addGitCommitFlags(amendCmd)
+
+	rootCmd.AddCommand(amendCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/cowboy-bebug/kommit/internal/breaking"
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+)
+
+// reviewBreakingChange asks the user to confirm a breaking change whenever
+// the heuristics or the model suspect one, and marks the message with "!"
+// and a BREAKING CHANGE footer accordingly. With --approve the model's
+// judgement stands.
+func reviewBreakingChange(diff, message string) string {
+	if Approve {
+		return message
+	}
+
+	parsed, err := conventional.Parse(message)
+	if err != nil {
+		// Nothing to mark on a message that isn't conventional
+		return message
+	}
+
+	findings := detectBreakingChanges(diff)
+	if len(findings) == 0 && !parsed.IsBreaking() {
+		return message
+	}
+
+	var description string
+	for _, footer := range parsed.Footers {
+		if footer.IsBreakingChange() {
+			description = footer.Value
+		}
+	}
+
+	isBreaking, description, err := ui.ConfirmBreakingChange(findings, parsed.IsBreaking(), description)
+	handleQuitError(err)
+	if err != nil {
+		log.Printf("Error confirming breaking change: %v", err)
+		exit(1)
+	}
+
+	if isBreaking {
+		parsed.SetBreakingChange(description)
+	} else {
+		parsed.ClearBreakingChange()
+	}
+	return parsed.String()
+}
+
+// detectBreakingChanges returns what the heuristics suspect in a diff, one
+// line per finding.
+func detectBreakingChanges(diff string) []string {
+	detected := breaking.Detect(diff)
+	findings := make([]string, len(detected))
+	for i, finding := range detected {
+		findings[i] = finding.String()
+	}
+	return findings
+}
+
+// unmarkedBreakingChanges returns the suspected breaking changes of a diff
+// when the message doesn't mark one. Commands that can't stop to ask, like
+// generate and the hook, pass these on instead of confirming them.
+func unmarkedBreakingChanges(diff, message string) []string {
+	if parsed, err := conventional.Parse(message); err == nil && parsed.IsBreaking() {
+		return nil
+	}
+	return detectBreakingChanges(diff)
+}
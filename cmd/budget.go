@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/cowboy-bebug/kommit/internal/utils"
+)
+
+// enforceBudget must be called before every LLM call. It warns when spending
+// approaches a monthly budget and refuses to continue once one is exhausted,
+// unless --override-budget is set.
+func enforceBudget(cmd CmdType, budget utils.BudgetConfig) {
+	report, err := utils.CheckBudget(budget)
+	if err != nil {
+		fmt.Printf("%s: Couldn't check your therapy budget!\n", getErrorPrefix(cmd))
+		if Verbose {
+			log.Printf("Error checking budget: %v", err)
+		}
+		exit(1)
+	}
+
+	for _, usage := range report.Warnings() {
+		fmt.Printf("💸 Your %s therapy budget is running low: $%.5f of $%.2f spent this month (%.0f%%).\n",
+			usage.Scope, usage.Spent, usage.Limit, usage.Percent())
+	}
+
+	exceeded := report.Exceeded()
+	if len(exceeded) == 0 {
+		return
+	}
+
+	for _, usage := range exceeded {
+		fmt.Printf("💸 Your %s therapy budget is exhausted: $%.5f of $%.2f spent this month.\n",
+			usage.Scope, usage.Spent, usage.Limit)
+	}
+
+	if OverrideBudget {
+		fmt.Println("🧐 Budget overridden. Your therapist appreciates the extra session.")
+		return
+	}
+
+	fmt.Printf("%s: Your therapist doesn't work pro bono!\n", getErrorPrefix(cmd))
+	fmt.Println("(Raise `budget.monthly` or pass --override-budget to continue.)")
+	exit(1)
+}
+
+// budgetExhausted reports, without printing anything, whether a further call
+// would overrun a budget. Loops that make several calls check it between
+// them, after enforceBudget has warned once.
+func budgetExhausted(budget utils.BudgetConfig) bool {
+	if OverrideBudget {
+		return false
+	}
+	report, err := utils.CheckBudget(budget)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error checking budget: %v", err)
+		}
+		return true
+	}
+	return len(report.Exceeded()) > 0
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/cowboy-bebug/kommit/internal/changelog"
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	usageChangelogFrom    = "Start after this ref (default: the latest tag before --to)"
+	usageChangelogTo      = "End at this ref"
+	usageChangelogRelease = "Name the release (default: the tag at --to, or Unreleased)"
+	usageChangelogWrite   = "Update the changelog file (CHANGELOG.md by default) instead of printing"
+	usageChangelogPolish  = "Have the therapist turn terse subjects into release notes"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "📜 Write a changelog from your conventional history",
+	Long: `📜 Kommit Changelog - Your progress notes, ready to share!
+
+This command reads the conventional commits since the latest tag (or --from)
+and writes a Keep a Changelog section: commits grouped by type and scope,
+breaking changes up front, and commit hashes and references linked to your
+repo. Print it, or update CHANGELOG.md in place with --write.
+
+Which types make it in, and under which headings, is set next to your commit
+types in .kommitrc.yaml. With --polish, the therapist rewrites terse subjects
+as notes your users will understand.`,
+	Args: cobra.NoArgs,
+	Run:  runChangelog,
+}
+
+func runChangelog(cmd *cobra.Command, args []string) {
+	if !utils.RevExists(ChangelogTo) {
+		exitScript(ChangelogCmd, 1, "Couldn't find %s.", ChangelogTo)
+	}
+
+	from := ChangelogFrom
+	if from == "" && utils.RevExists(ChangelogTo+"^") {
+		// No tag yet means the whole history
+		from, _ = utils.GetLatestTag(ChangelogTo + "^")
+	}
+	revisionRange := ChangelogTo
+	if from != "" {
+		revisionRange = from + ".." + ChangelogTo
+	}
+
+	commits, err := utils.GetCommitMessages(revisionRange)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error reading commits: %v", err)
+		}
+		exitScript(ChangelogCmd, 1, "Couldn't read the commits in %s.", revisionRange)
+	}
+
+	config, err := utils.LoadConfig()
+	if err != nil {
+		HandleUnsupportedModelError(ChangelogCmd, err)
+		if Verbose {
+			log.Printf("Error loading config, using the default sections: %v", err)
+		}
+		if config, err = utils.GetDefaultConfig(); err != nil {
+			exitScript(ChangelogCmd, exitConfigMissing, "Couldn't load a treatment plan.")
+		}
+	}
+
+	entries, skipped := changelog.Entries(commits, config.Commit.References.Token)
+	if skipped > 0 && Verbose {
+		log.Printf("Skipped %d commits that aren't conventional", skipped)
+	}
+
+	version := ChangelogRelease
+	if version == "" {
+		version = utils.GetExactTag(ChangelogTo)
+	}
+	date := ""
+	if version == "" {
+		version = changelog.Unreleased
+	} else if date, err = utils.GetCommitDate(ChangelogTo); err != nil && Verbose {
+		log.Printf("Error reading release date: %v", err)
+	}
+
+	repoURL := config.Commit.Changelog.URL
+	if repoURL == "" {
+		if remote, err := utils.ExecGit("config", "--get", "remote.origin.url"); err == nil {
+			repoURL = changelog.WebURL(remote)
+		}
+	}
+
+	release := changelog.Build(version, date, entries, config.Commit.Changelog)
+	compareTo := version
+	if version == changelog.Unreleased {
+		compareTo = "HEAD"
+	}
+	release.CompareURL = changelog.CompareURL(repoURL, from, compareTo)
+
+	if ChangelogPolish || config.Commit.Changelog.Polish {
+		polishRelease(config, &release)
+	}
+
+	section := release.Markdown(repoURL, config.Commit.Changelog.BreakingTitle)
+	if !ChangelogWrite {
+		fmt.Print(section)
+		return
+	}
+
+	writeChangelog(config, section, version)
+}
+
+// polishRelease rewrites the entries' descriptions as release notes, keeping
+// the originals if the therapist is unavailable.
+func polishRelease(config *utils.Config, release *changelog.Release) {
+	var entries []*changelog.Entry
+	for i := range release.Breaking {
+		entries = append(entries, &release.Breaking[i])
+	}
+	for i := range release.Sections {
+		for j := range release.Sections[i].Entries {
+			entries = append(entries, &release.Sections[i].Entries[j])
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	enforceScriptBudget(ChangelogCmd, config.Budget)
+
+	descriptions := make([]string, len(entries))
+	for i, entry := range entries {
+		descriptions[i] = entry.Description
+		if entry.Scope != "" {
+			descriptions[i] = entry.Scope + ": " + entry.Description
+		}
+	}
+
+	s := ui.Spinner("🧐 Turning your commit notes into something your users can relate to...")
+	s.Start()
+	result, err := llm.PolishReleaseNotes(config.LLM.Model, descriptions)
+	s.Stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️  Your therapist couldn't polish the notes, so they're written as committed.")
+		if Verbose {
+			log.Printf("Error polishing release notes: %v", err)
+		}
+		return
+	}
+	recordCost("changelog", result)
+
+	for _, note := range result.Message.Notes {
+		if note.Entry >= 0 && note.Entry < len(entries) && note.Text != "" {
+			entries[note.Entry].Description = note.Text
+		}
+	}
+}
+
+func writeChangelog(config *utils.Config, section, version string) {
+	path := config.Commit.Changelog.File
+	if path == "" {
+		path = changelog.DefaultFile
+	}
+	if !filepath.IsAbs(path) {
+		if root, err := utils.GetConfigPath(); err == nil {
+			path = filepath.Join(root, path)
+		}
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		if Verbose {
+			log.Printf("Error reading changelog: %v", err)
+		}
+		exitScript(ChangelogCmd, 1, "Couldn't read %s.", path)
+	}
+
+	if err := os.WriteFile(path, []byte(changelog.Update(string(existing), section, version)), 0644); err != nil {
+		if Verbose {
+			log.Printf("Error writing changelog: %v", err)
+		}
+		exitScript(ChangelogCmd, 1, "Couldn't write %s.", path)
+	}
+	fmt.Printf("📜 Your progress notes for %s are in %s.\n", version, path)
+}
+
+var ChangelogFrom string
+var ChangelogTo string
+var ChangelogRelease string
+var ChangelogWrite bool
+var ChangelogPolish bool
+
+func init() {
+	changelogCmd.Flags().StringVar(&ChangelogFrom, "from", "", usageChangelogFrom)
+	changelogCmd.Flags().StringVar(&ChangelogTo, "to", "HEAD", usageChangelogTo)
+	changelogCmd.Flags().StringVar(&ChangelogRelease, "release", "", usageChangelogRelease)
+	changelogCmd.Flags().BoolVarP(&ChangelogWrite, "write", "w", false, usageChangelogWrite)
+	changelogCmd.Flags().BoolVar(&ChangelogPolish, "polish", false, usageChangelogPolish)
+
+	rootCmd.AddCommand(changelogCmd)
+}
@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/cowboy-bebug/kommit/internal/format"
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/patch"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/fatih/color"
+)
+
+// loadConfig loads the repo's treatment plan, exiting if there is none.
+func loadConfig(cmd CmdType) *utils.Config {
+	config, err := utils.LoadConfig()
+	if err != nil {
+		HandleUnsupportedModelError(cmd, err)
+		fmt.Printf("%s: You haven't booked your first therapy session!\n", getErrorPrefix(cmd))
+		fmt.Println("(Run 'git kommit init' to get on the calendar.)")
+		if Verbose {
+			log.Printf("Error loading config: %v", err)
+		}
+		exit(1)
+	}
+	return config
+}
+
+// generateCommitMessage asks the therapist for a message describing the
+// diff, exiting on failure. The call is checked against the budget and
+// recorded in the cost ledger under the given command name.
+func generateCommitMessage(cmd CmdType, config *utils.Config, diff, command string) llm.ChatResult[string] {
+	enforceBudget(cmd, config.Budget)
+
+	s := ui.Spinner("🧐 Helping your code express its feelings to future developers...")
+	s.Start()
+	result, err := llm.GenerateCommitMessage(config, diff, Message)
+	s.Stop()
+	if err != nil {
+		fmt.Printf("%s: Your code is experiencing emotional resistance!\n", getErrorPrefix(cmd))
+		if errors.Is(err, &llm.APIKeyMissingError{}) {
+			fmt.Println("\nHave you set up your OpenAI API key? Try one of these:")
+			fmt.Println("  export OPENAI_API_KEY=\"sk-...\"")
+			fmt.Println("  export KOMMIT_OPENAI_API_KEY=\"sk-...\"    # For a dedicated key")
+		}
+		if Verbose {
+			log.Printf("Error generating commit message: %v", err)
+		}
+		exit(1)
+	}
+	recordCost(command, result)
+
+	return repairCommitMessage(config, diff, command, result)
+}
+
+// configRules returns the repo's rules, with the lengths from commit.format.
+func configRules(config *utils.Config) lint.Rules {
+	rules := lint.DefaultRules(config.Commit.Types, config.Commit.Scopes)
+	if config.Commit.Format.SubjectMaxLength > 0 {
+		rules.SubjectMaxLength = config.Commit.Format.SubjectMaxLength
+	}
+	if config.Commit.Format.BodyWidth > 0 {
+		rules.BodyMaxLineLength = config.Commit.Format.BodyWidth
+	}
+	return rules
+}
+
+// tidyMessage applies the safe lint fixes and the commit.format pipeline to a
+// generated message.
+func tidyMessage(config *utils.Config, message string) string {
+	message = lint.Fix(message, configRules(config))
+	formatted, err := format.Apply(message, config.Commit.Format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't format the message: %v\n", err)
+		return message
+	}
+	return formatted
+}
+
+// How many times the therapist gets to redo a message that breaks the rules
+const maxRepairAttempts = 2
+
+// repairCommitMessage fixes what lint.Fix can and asks the therapist to redo
+// the message for anything else, a few times at most. Whatever is still wrong
+// afterwards shows up on the review screen.
+func repairCommitMessage(config *utils.Config, diff, command string, result llm.ChatResult[string]) llm.ChatResult[string] {
+	rules := configRules(config)
+	result.Message = tidyMessage(config, result.Message)
+
+	for attempt := 0; attempt < maxRepairAttempts; attempt++ {
+		violations := lint.Lint(result.Message, rules)
+		if !lint.HasErrors(violations) {
+			break
+		}
+
+		if budgetExhausted(config.Budget) {
+			if Verbose {
+				log.Printf("Budget exhausted, leaving the message as it is")
+			}
+			break
+		}
+
+		var problems []string
+		for _, v := range violations {
+			if v.Severity == lint.SeverityError {
+				problems = append(problems, v.String())
+			}
+		}
+
+		s := ui.Spinner("🧐 Your code misspoke. Helping it rephrase...")
+		if Print {
+			s.Disable()
+		}
+		s.Start()
+		repaired, err := llm.RepairCommitMessage(config, diff, Message, result.Message, problems)
+		s.Stop()
+		if err != nil {
+			if Verbose {
+				log.Printf("Error repairing commit message: %v", err)
+			}
+			break
+		}
+		recordCost(command, repaired)
+
+		result.Message = tidyMessage(config, repaired.Message)
+		result.Usage.PromptTokens += repaired.Usage.PromptTokens
+		result.Usage.CachedTokens += repaired.Usage.CachedTokens
+		result.Usage.CompletionTokens += repaired.Usage.CompletionTokens
+		result.Cost += repaired.Cost
+	}
+
+	return result
+}
+
+// reviewCommitMessage shows the recommendation and asks what to do with it,
+// unless --approve or --edit already decided.
+func reviewCommitMessage(commitMessage string, violations []lint.Violation) ui.CommitOption {
+	if Approve {
+		return ui.CommitOptionProceed
+	}
+	if Edit {
+		return ui.CommitOptionEdit
+	}
+
+	fmt.Println("💭 Your therapist's recommendation:")
+	fmt.Println("```text")
+	color.New(color.FgGreen, color.Bold).Println(commitMessage)
+	fmt.Println("```")
+	printViolations(violations)
+
+	option, err := ui.SelectCommit()
+	handleQuitError(err)
+	if err != nil {
+		log.Printf("Error confirming commit: %v", err)
+		exit(1)
+	}
+	return option
+}
+
+// printViolations lists the rules a generated message breaks, so they can be
+// fixed in the editor or with a re-run.
+func printViolations(violations []lint.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+	fmt.Println("⚠️  Your therapist bent a few of your rules:")
+	for _, v := range violations {
+		fmt.Printf("   %s: %s\n", v.Severity, v)
+	}
+}
+
+// withSignature adds the ticket references from the branch name, the
+// configured attribution and trailers to a generated message, skipping any
+// step that fails.
+func withSignature(config *utils.Config, result llm.ChatResult[string]) string {
+	message := result.Message
+
+	references, err := utils.GetBranchReferences(config.Commit.References)
+	if err == nil {
+		message, err = utils.AddReferences(config.Commit.References, message, references, configRules(config).SubjectMaxLength)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't add ticket references: %v\n", err)
+		message = result.Message
+	}
+
+	return signMessage(config, message, result.Model)
+}
+
+// signMessage adds the configured attribution and trailers, but no ticket
+// references, for messages describing changes that needn't belong to the
+// current branch.
+func signMessage(config *utils.Config, message, model string) string {
+	signed, err := utils.SignMessage(config.Commit, message, model)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't sign the message: %v\n", err)
+		return message
+	}
+	return signed
+}
+
+// writeTempMessage writes the message to a temporary file for `git commit -F`.
+// The caller removes the file.
+func writeTempMessage(cmd CmdType, commitMessage string) string {
+	tempFile, err := os.CreateTemp("", ".kommit-msg-*.txt")
+	if err != nil {
+		fmt.Printf("%s: Refusing to prepare temporary paperwork!\n", getErrorPrefix(cmd))
+		if Verbose {
+			log.Printf("Error creating temp file: %v", err)
+		}
+		exit(1)
+	}
+	tempFilePath := tempFile.Name()
+
+	if _, err := tempFile.WriteString(commitMessage); err != nil {
+		tempFile.Close()
+		os.Remove(tempFilePath)
+		fmt.Printf("%s: Refusing to fill the temporary paperwork!\n", getErrorPrefix(cmd))
+		if Verbose {
+			log.Printf("Error writing to temp file: %v", err)
+		}
+		exit(1)
+	}
+	tempFile.Close()
+
+	return tempFilePath
+}
+
+// runGitCommit runs `git commit` attached to the terminal, with kommit's own
+// hooks told to stay out of the way.
+func runGitCommit(args ...string) error {
+	cmd := exec.Command("git", append([]string{"commit"}, args...)...)
+	cmd.Env = append(os.Environ(), utils.HookSkipEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// commitWithMessage commits with the message as-is (proceed) or after
+// opening it in the editor (edit). With amend, HEAD is amended instead.
+func commitWithMessage(cmd CmdType, option ui.CommitOption, commitMessage string, amend bool) {
+	tempFilePath := writeTempMessage(cmd, commitMessage)
+	defer os.Remove(tempFilePath) // Clean up the temp file when done
+
+	var args []string
+	if amend {
+		args = append(args, "--amend")
+	}
+	args = append(args, gitCommitArgs()...)
+
+	switch option {
+	case ui.CommitOptionProceed:
+		fmt.Println("🧐 Preparing for your code's commitment ceremony...")
+
+		if err := runGitCommit(append(args, "-q", "-F", tempFilePath)...); err != nil {
+			os.Remove(tempFilePath)
+			fmt.Printf("%s: Refusing to commit!\n", getErrorPrefix(cmd))
+			if Verbose {
+				log.Printf("Error committing: %v", err)
+			}
+			exit(1)
+		}
+		fmt.Println("🧐 Successfully committed! Your relationship with the repo has deepened!")
+	case ui.CommitOptionEdit:
+		fmt.Println("🧐 Starting your self-guided therapy session...")
+
+		// --template is ignored when amending, so pre-fill with -e -F instead
+		if amend {
+			args = append(args, "-e", "-F", tempFilePath)
+		} else {
+			args = append(args, "--template", tempFilePath, "--allow-empty-message")
+		}
+
+		fmt.Println("📝 Opening your personal therapy journal (editor)...")
+
+		if err := runGitCommit(args...); err != nil {
+			os.Remove(tempFilePath)
+			fmt.Printf("%s: Your self-therapy session was interrupted!\n", getErrorPrefix(cmd))
+			if Verbose {
+				log.Printf("Error during commit: %v", err)
+			}
+			exit(1)
+		}
+
+		fmt.Println("🎓 Self-therapy complete! You've committed to your own path of growth.")
+	}
+}
+
+// stageAll stages every tracked change (and untracked files with
+// --include-untracked), keeping the index as it was before.
+func stageAll() {
+	backup, err := utils.BackupIndex()
+	if err != nil {
+		fmt.Printf("%s: Couldn't keep a backup of your index, so nothing was staged.\n", getErrorPrefix(RootCmd))
+		if Verbose {
+			log.Printf("Error backing up the index: %v", err)
+		}
+		exit(1)
+	}
+	keepIndexBeforeStaging(backup)
+
+	if err := utils.StageAll(IncludeUntracked); err != nil {
+		fmt.Printf("%s: Your changes refused to open up!\n", getErrorPrefix(RootCmd))
+		if Verbose {
+			log.Printf("Error staging changes: %v", err)
+		}
+		exit(1)
+	}
+}
+
+// stagePatch lets the user pick unstaged hunks and stages them, keeping the
+// index as it was before.
+func stagePatch() {
+	diff, err := utils.ExecGit("diff", "--binary")
+	if err != nil || diff == "" {
+		if Verbose && err != nil {
+			log.Printf("Error getting unstaged changes: %v", err)
+		}
+		fmt.Println("🧐 No unstaged changes to pick from, so let's talk about what's staged.")
+		return
+	}
+
+	files, err := patch.Parse(diff)
+	if err != nil {
+		fmt.Printf("%s: Your unstaged changes are too tangled to read.\n", getErrorPrefix(RootCmd))
+		if Verbose {
+			log.Printf("Error parsing diff: %v", err)
+		}
+		exit(1)
+	}
+
+	units, err := ui.SelectHunks(patch.Units(files))
+	handleQuitError(err)
+	if err != nil {
+		log.Printf("Error selecting hunks: %v", err)
+		exit(1)
+	}
+	if len(units) == 0 {
+		return
+	}
+
+	backup, err := utils.BackupIndex()
+	if err != nil {
+		fmt.Printf("%s: Couldn't keep a backup of your index, so nothing was staged.\n", getErrorPrefix(RootCmd))
+		if Verbose {
+			log.Printf("Error backing up the index: %v", err)
+		}
+		exit(1)
+	}
+	keepIndexBeforeStaging(backup)
+
+	selection := patch.Series([]patch.Group{{Units: units}})[0]
+	if _, err := utils.ExecGitWithInput(selection, nil, "apply", "--cached", "-"); err != nil {
+		fmt.Printf("%s: Your chosen changes refused to open up!\n", getErrorPrefix(RootCmd))
+		if Verbose {
+			log.Printf("Error staging hunks: %v", err)
+		}
+		exit(1)
+	}
+}
+
+// keepIndexBeforeStaging remembers the index from before --all or --patch
+// staged anything, and puts it back if kommit exits without committing.
+func keepIndexBeforeStaging(backup *utils.IndexBackup) {
+	indexBeforeStaging = backup
+	atExit(restoreIndexBeforeStaging)
+}
+
+func restoreIndexBeforeStaging() {
+	if indexBeforeStaging == nil {
+		return
+	}
+	if err := indexBeforeStaging.Restore(); err != nil && Verbose {
+		log.Printf("Error restoring the index: %v", err)
+	}
+}
+
+// warnPartiallyStaged points out files whose unstaged changes won't be part
+// of the commit, or of the message describing it.
+func warnPartiallyStaged() {
+	files, err := utils.GetPartiallyStagedFiles()
+	if err != nil {
+		if Verbose {
+			log.Printf("Error checking for partially staged files: %v", err)
+		}
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	fmt.Println("⚠️  Some files are only partially staged, so the message only covers their staged part:")
+	for _, file := range files {
+		fmt.Printf("   %s\n", file)
+	}
+	fmt.Println("(Use --all to bring everything to the session, or --patch to pick.)")
+}
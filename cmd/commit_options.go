@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// gpgSignNoKey is what pflag sets for a bare -S. pflag can't leave an
+	// optional value empty, and no key is a lone space, so it's trimmed back
+	// to "": signing with the default key.
+	gpgSignNoKey = " "
+
+	usageGPGSign  = "GPG-sign the commit, like git commit -S. Pick a key with --gpg-sign=<keyid> (-S<keyid> doesn't work)"
+	usageSignoff  = "Add a Signed-off-by trailer, like git commit -s"
+	usageAuthor   = "Override the commit author, like git commit --author"
+	usageDate     = "Override the author date, like git commit --date"
+	usageNoVerify = "Skip the pre-commit and commit-msg hooks, like git commit --no-verify"
+	usageCleanup  = "How to clean up the message, like git commit --cleanup"
+)
+
+// Options forwarded to `git commit`, plus anything after "--"
+var (
+	CommitGPGSign string
+	// Whether -S was given at all, since its key may be empty
+	CommitGPGSigned bool
+	CommitSignoff   bool
+	CommitAuthor    string
+	CommitDate      string
+	CommitNoVerify  bool
+	CommitCleanup   string
+	CommitExtra     []string
+)
+
+// addGitCommitFlags registers the options forwarded to `git commit` on a
+// command that commits.
+func addGitCommitFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&CommitGPGSign, "gpg-sign", "S", "", usageGPGSign)
+	cmd.Flags().Lookup("gpg-sign").NoOptDefVal = gpgSignNoKey
+	cmd.Flags().BoolVarP(&CommitSignoff, "signoff", "s", false, usageSignoff)
+	cmd.Flags().StringVar(&CommitAuthor, "author", "", usageAuthor)
+	cmd.Flags().StringVar(&CommitDate, "date", "", usageDate)
+	cmd.Flags().BoolVarP(&CommitNoVerify, "no-verify", "n", false, usageNoVerify)
+	cmd.Flags().StringVar(&CommitCleanup, "cleanup", "", usageCleanup)
+
+	cmd.Args = passthroughArgs
+}
+
+// passthroughArgs only accepts arguments after "--" and keeps them for
+// `git commit`, along with whether -S was given.
+func passthroughArgs(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	if len(args) > 0 && dash != 0 {
+		return fmt.Errorf("unknown command %q for %q\n(Arguments for git commit go after --)", args[0], cmd.CommandPath())
+	}
+	CommitExtra = args
+	CommitGPGSigned = cmd.Flags().Changed("gpg-sign")
+	CommitGPGSign = strings.TrimSpace(CommitGPGSign)
+	return nil
+}
+
+// gitCommitArgs returns the options to forward to `git commit`.
+func gitCommitArgs() []string {
+	var args []string
+	switch {
+	case !CommitGPGSigned:
+	case CommitGPGSign == "":
+		args = append(args, "--gpg-sign")
+	default:
+		args = append(args, "--gpg-sign="+CommitGPGSign)
+	}
+	if CommitSignoff {
+		args = append(args, "--signoff")
+	}
+	if CommitAuthor != "" {
+		args = append(args, "--author="+CommitAuthor)
+	}
+	if CommitDate != "" {
+		args = append(args, "--date="+CommitDate)
+	}
+	if CommitNoVerify {
+		args = append(args, "--no-verify")
+	}
+	if CommitCleanup != "" {
+		args = append(args, "--cleanup="+CommitCleanup)
+	}
+	return append(args, CommitExtra...)
+}
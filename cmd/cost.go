@@ -3,13 +3,22 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"log"
 	"os"
 
+	"github.com/cowboy-bebug/kommit/internal/llm"
 	"github.com/cowboy-bebug/kommit/internal/ui"
 	"github.com/cowboy-bebug/kommit/internal/utils"
 	"github.com/spf13/cobra"
 )
 
+const (
+	usageCostSince = "Only count sessions on or after this date (YYYY-MM-DD, YYYY-MM or RFC 3339)"
+	usageCostUntil = "Only count sessions up to and including this date"
+	usageCostBy    = "Group expenses by repo, model, month, day or command"
+	usageCostFmt   = "Print expenses as table, json or csv"
+)
+
 var costCmd = &cobra.Command{
 	Use:   "cost",
 	Short: "💰 Show the cost of your commitment therapy",
@@ -28,24 +37,105 @@ for the investment in clear, meaningful commit history.`,
 }
 
 func runCost(cmd *cobra.Command, args []string) {
-	costs, err := utils.GetCosts()
+	format, err := utils.ParseCostFormat(CostFormat)
+	if err != nil {
+		fmt.Printf("😰 Financial abandonment detected: Can't print your expenses as %q.\n", CostFormat)
+		fmt.Println("(Try one of: table, json, csv)")
+		exit(1)
+	}
+
+	by, err := utils.ParseCostGroupBy(CostBy)
+	if err != nil {
+		fmt.Printf("😰 Financial abandonment detected: Can't group your expenses by %q.\n", CostBy)
+		fmt.Println("(Try one of: repo, model, month, day, command)")
+		exit(1)
+	}
+
+	var filter utils.CostFilter
+	if CostSince != "" {
+		if filter.Since, err = utils.ParseCostTime(CostSince, false); err != nil {
+			fmt.Printf("😰 Financial abandonment detected: %v\n", err)
+			exit(1)
+		}
+	}
+	if CostUntil != "" {
+		if filter.Until, err = utils.ParseCostTime(CostUntil, true); err != nil {
+			fmt.Printf("😰 Financial abandonment detected: %v\n", err)
+			exit(1)
+		}
+	}
+
+	entries, err := utils.GetCosts(filter)
+	if err != nil {
+		// Scripts get an empty report rather than a friendly apology
+		if errors.Is(err, utils.CostFileNotFoundError{}) && format != utils.CostFormatTable {
+			entries, err = nil, nil
+		}
+	}
 	if err != nil {
 		if errors.Is(err, utils.CostFileNotFoundError{}) {
 			fmt.Println("😰 Financial abandonment detected: It hasn't committed any expenses yet.")
 			fmt.Println("(Have you run `git kommit` yet?)")
-			os.Exit(0)
+			exit(0)
 		}
 		fmt.Println("😰 Financial abandonment detected: Failed to retrieve your expenses.")
-		os.Exit(1)
+		if Verbose {
+			log.Printf("Error reading costs: %v", err)
+		}
+		exit(1)
 	}
 
-	if err = ui.CostTableModel(costs); err != nil {
-		ui.HandleQuitError(err)
-		fmt.Println("😰 Financial abandonment detected: Failed to display your expenses.")
-		os.Exit(1)
+	groups := utils.GroupCosts(entries, by)
+	switch {
+	case format == utils.CostFormatJSON:
+		err = utils.WriteCostsJSON(os.Stdout, groups, by)
+	case format == utils.CostFormatCSV:
+		err = utils.WriteCostsCSV(os.Stdout, groups, by)
+	case !ui.IsTerminal():
+		err = ui.PrintCostTable(os.Stdout, groups, by)
+	default:
+		err = ui.CostTableModel(entries, filter, by)
+		handleQuitError(err)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "😰 Financial abandonment detected: Failed to display your expenses.")
+		if Verbose {
+			log.Printf("Error displaying costs: %v", err)
+		}
+		exit(1)
 	}
 }
 
+// recordCost adds an LLM call to the cost ledger. Failing to record is
+// reported but never fatal: the session has already been paid for.
+func recordCost[T any](command string, result llm.ChatResult[T]) {
+	err := utils.RecordCost(utils.CostEntry{
+		Command:          command,
+		Provider:         result.Provider,
+		Model:            result.Model,
+		PromptTokens:     result.Usage.PromptTokens,
+		CachedTokens:     result.Usage.CachedTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+		Cost:             utils.Cost(result.Cost),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "💸 Couldn't file this session's bill. `kommit cost` will be missing it.")
+		if Verbose {
+			log.Printf("Error recording cost: %v", err)
+		}
+	}
+}
+
+var CostSince string
+var CostUntil string
+var CostBy string
+var CostFormat string
+
 func init() {
+	costCmd.Flags().StringVar(&CostSince, "since", "", usageCostSince)
+	costCmd.Flags().StringVar(&CostUntil, "until", "", usageCostUntil)
+	costCmd.Flags().StringVar(&CostBy, "by", string(utils.CostGroupByRepo), usageCostBy)
+	costCmd.Flags().StringVar(&CostFormat, "format", string(utils.CostFormatTable), usageCostFmt)
+
 	rootCmd.AddCommand(costCmd)
 }
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
+	"github.com/cowboy-bebug/kommit/internal/ui"
 	"github.com/cowboy-bebug/kommit/internal/utils"
 )
 
@@ -14,12 +18,32 @@ const (
 	InitCmd CmdType = iota
 	RootCmd
 	VersionCmd
+	HookCmd
+	LintCmd
+	AmendCmd
+	RewordCmd
+	SplitCmd
+	GenerateCmd
+	PrCmd
+	ChangelogCmd
+	NextVersionCmd
+	SquashCmd
 )
 
 var cmdErrorPrefix = map[CmdType]string{
-	InitCmd:    "😰 Therapy session interrupted",
-	RootCmd:    "😰 Commitment issues detected",
-	VersionCmd: "No errors are returned from this command.",
+	InitCmd:        "😰 Therapy session interrupted",
+	RootCmd:        "😰 Commitment issues detected",
+	VersionCmd:     "No errors are returned from this command.",
+	HookCmd:        "😰 Therapy hook malfunction",
+	LintCmd:        "😰 Commit message malpractice",
+	AmendCmd:       "😰 Relapse prevention failed",
+	RewordCmd:      "😰 Group therapy interrupted",
+	SplitCmd:       "😰 Separation therapy failed",
+	GenerateCmd:    "😰 Diagnosis withheld",
+	PrCmd:          "😰 Case presentation cancelled",
+	ChangelogCmd:   "😰 Progress notes misplaced",
+	NextVersionCmd: "😰 Growth assessment failed",
+	SquashCmd:      "😰 Group consolidation failed",
 }
 
 func getErrorPrefix(cmd CmdType) string {
@@ -30,6 +54,67 @@ func HandleUnsupportedModelError(cmd CmdType, err error) {
 	if errors.Is(err, utils.UnsupportedModelError{}) {
 		fmt.Printf("%s: The therapist's qualification looks sus!\n", getErrorPrefix(cmd))
 		fmt.Println("(Check your .kommitrc.yaml for supported models)")
-		os.Exit(1)
+		exit(1)
 	}
 }
+
+// exitHooks undo what a command changed before it exits early, e.g. put back
+// the index that --all or --patch staged.
+var (
+	exitHooks   []func()
+	exitHooksMu sync.Mutex
+	exitSignals chan os.Signal
+)
+
+// atExit registers a function to run if the command exits early, including
+// on Ctrl-C or a kill.
+func atExit(hook func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+
+	exitHooks = append(exitHooks, hook)
+	if exitSignals == nil {
+		exitSignals = make(chan os.Signal, 1)
+		signal.Notify(exitSignals, os.Interrupt, syscall.SIGTERM)
+		go exitOnSignal()
+	}
+}
+
+// exitOnSignal runs the exit hooks when the command is interrupted, exiting
+// like the shell reports a killed process (130 for Ctrl-C).
+func exitOnSignal() {
+	sig := <-exitSignals
+	code := 1
+	if s, ok := sig.(syscall.Signal); ok {
+		code = 128 + int(s)
+	}
+	exit(code)
+}
+
+// runExitHooks runs the exit hooks once. They hold the lock while they run,
+// so a signal arriving meanwhile waits for them instead of cutting them short.
+func runExitHooks() {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+	exitHooks = nil
+}
+
+// exit runs the exit hooks, most recent first, and exits with the code. Use
+// it instead of os.Exit.
+func exit(code int) {
+	runExitHooks()
+	os.Exit(code)
+}
+
+// handleQuitError is ui.HandleQuitError, running the exit hooks before
+// quitting.
+func handleQuitError(err error) {
+	if errors.Is(err, ui.QuitError{}) {
+		runExitHooks()
+	}
+	ui.HandleQuitError(err)
+}
@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/patch"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of `kommit generate`, so scripts can tell failures apart
+const (
+	exitNothingStaged   = 2
+	exitConfigMissing   = 3
+	exitProviderError   = 4
+	exitBudgetExhausted = 5
+
+	usagePrint         = "Print the message instead of committing, like `kommit generate`"
+	usageJSON          = "Print a JSON array of messages, with their parts, model, cost and tokens"
+	usageGenerateDiff  = "Describe a diff, patch or mbox of patches from a file (or - for stdin) instead of the staged changes"
+	usageGenerateRange = "Describe the changes in a range (e.g. origin/main..HEAD) instead of the staged changes"
+	usagePrintJSON     = "Like --print, but as a JSON array with the message's parts, model, cost and tokens"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "📋 Print a commit message for your staged changes",
+	Long: `📋 Kommit Generate - A diagnosis to take home!
+
+This command writes the therapist's message for your staged changes to stdout
+and nothing else: no prompts, no spinners and no commit. It's meant for
+scripts, editors and git GUIs. Use --json for the message's type, scope,
+subject, body and footers along with the model, cost and tokens used, as an
+array with an entry per message.
+
+It describes any diff, not just the staged one: pass --range for the changes
+between two commits, or --diff for a diff or patch file (- reads stdin). An
+mbox, such as ` + "`git format-patch --stdout`" + ` output or a mailing-list thread,
+gets a message for each patch.
+
+Failures go to stderr with an exit code to match:
+  2  there's nothing to describe (e.g. nothing is staged)
+  3  the repo has no (valid) .kommitrc.yaml
+  4  the model provider failed
+  5  the therapy budget is exhausted`,
+	Args: cobra.NoArgs,
+	Run:  runGenerate,
+}
+
+type generateTokens struct {
+	Prompt     int64 `json:"prompt"`
+	Cached     int64 `json:"cached"`
+	Completion int64 `json:"completion"`
+}
+
+type generateOutput struct {
+	Source     string           `json:"source,omitempty"`
+	Type       string           `json:"type"`
+	Scope      string           `json:"scope"`
+	Breaking   bool             `json:"breaking"`
+	Subject    string           `json:"subject"`
+	Body       string           `json:"body"`
+	Footers    []string         `json:"footers"`
+	Message    string           `json:"message"`
+	Model      string           `json:"model"`
+	Cost       float64          `json:"cost"`
+	Tokens     generateTokens   `json:"tokens"`
+	Violations []lint.Violation `json:"violations"`
+	// Suspected breaking changes, whether or not the message marks them
+	PossibleBreaking []string `json:"possible_breaking"`
+}
+
+// exitScript reports a failure on stderr, keeping stdout clean for the
+// output of commands meant for scripts.
+func exitScript(cmd CmdType, code int, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", getErrorPrefix(cmd), fmt.Sprintf(format, args...))
+	exit(code)
+}
+
+func exitGenerate(code int, format string, args ...any) {
+	exitScript(GenerateCmd, code, format, args...)
+}
+
+// loadScriptConfig is loadConfig for commands meant for scripts.
+func loadScriptConfig(cmd CmdType) *utils.Config {
+	config, err := utils.LoadConfig()
+	if err != nil {
+		if Verbose {
+			log.Printf("Error loading config: %v", err)
+		}
+		if errors.Is(err, utils.UnsupportedModelError{}) {
+			exitScript(cmd, exitConfigMissing, "The therapist's qualification looks sus! Check your .kommitrc.yaml for supported models.")
+		}
+		exitScript(cmd, exitConfigMissing, "You haven't booked your first therapy session! Run 'git kommit init' first.")
+	}
+	return config
+}
+
+// enforceScriptBudget is enforceBudget for commands meant for scripts.
+func enforceScriptBudget(cmd CmdType, budget utils.BudgetConfig) {
+	report, err := utils.CheckBudget(budget)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error checking budget: %v", err)
+		}
+		exitScript(cmd, 1, "Couldn't check your therapy budget!")
+	}
+	for _, usage := range report.Warnings() {
+		fmt.Fprintf(os.Stderr, "💸 Your %s therapy budget is running low: $%.5f of $%.2f spent this month (%.0f%%).\n",
+			usage.Scope, usage.Spent, usage.Limit, usage.Percent())
+	}
+	if len(report.Exceeded()) > 0 && !OverrideBudget {
+		exitScript(cmd, exitBudgetExhausted, "Your therapist doesn't work pro bono! (--override-budget books the session anyway.)")
+	}
+}
+
+// exitProviderFailure reports a failed model call for commands meant for
+// scripts.
+func exitProviderFailure(cmd CmdType, err error) {
+	if Verbose {
+		log.Printf("Error calling the model: %v", err)
+	}
+	if errors.Is(err, &llm.APIKeyMissingError{}) {
+		exitScript(cmd, exitProviderError, "Have you set up your OpenAI API key? Export OPENAI_API_KEY or KOMMIT_OPENAI_API_KEY.")
+	}
+	exitScript(cmd, exitProviderError, "Your code is experiencing emotional resistance!")
+}
+
+// generateInput is a diff to write a message for. Source names it when a
+// series of patches is read at once. Only staged changes belong to the
+// current branch, and so get its ticket references.
+type generateInput struct {
+	Source string
+	Diff   string
+	Staged bool
+}
+
+func runGenerate(cmd *cobra.Command, args []string) {
+	// Nothing below may prompt or draw on stdout
+	Print = true
+
+	inputs, series := readGenerateInputs()
+
+	config := loadScriptConfig(GenerateCmd)
+	enforceScriptBudget(GenerateCmd, config.Budget)
+
+	outputs := make([]generateOutput, len(inputs))
+	for i, input := range inputs {
+		if i > 0 && budgetExhausted(config.Budget) {
+			exitScript(GenerateCmd, exitBudgetExhausted, "Your therapy budget ran out after %d of %d patches! (--override-budget books the rest anyway.)", i, len(inputs))
+		}
+		result, err := llm.GenerateCommitMessage(config, input.Diff, Message)
+		if err != nil {
+			exitProviderFailure(GenerateCmd, err)
+		}
+		recordCost("generate", result)
+		result = repairCommitMessage(config, input.Diff, "generate", result)
+
+		outputs[i] = newGenerateOutput(config, input, result)
+	}
+
+	printGenerated(outputs, series)
+}
+
+// readGenerateInputs returns the diffs to describe: the staged changes, a
+// range, or a diff or mbox of patches from a file or stdin. series is set for
+// mboxes, which get a message per patch.
+func readGenerateInputs() ([]generateInput, bool) {
+	var diff string
+	switch {
+	case GenerateDiff != "" && GenerateRange != "":
+		exitGenerate(1, "--diff and --range are mixed signals. Pick one.")
+	case GenerateRange != "":
+		if !strings.Contains(GenerateRange, "..") {
+			exitGenerate(1, "%q isn't a range. Try something like origin/main..HEAD.", GenerateRange)
+		}
+		var err error
+		diff, err = utils.ExecGit("diff", GenerateRange)
+		if err != nil {
+			if Verbose {
+				log.Printf("Error reading range: %v", err)
+			}
+			exitGenerate(1, "Couldn't read the changes in %s.", GenerateRange)
+		}
+	case GenerateDiff != "":
+		var data []byte
+		var err error
+		if GenerateDiff == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(GenerateDiff)
+		}
+		if err != nil {
+			if Verbose {
+				log.Printf("Error reading diff: %v", err)
+			}
+			exitGenerate(1, "Couldn't read the diff to examine.")
+		}
+		diff = string(data)
+	default:
+		staged, err := utils.ExecGit("diff", "--cached")
+		if err != nil || staged == "" {
+			exitGenerate(exitNothingStaged, "You're not ready to commit... anything. Stage some changes first!")
+		}
+		return []generateInput{{Diff: staged, Staged: true}}, false
+	}
+
+	if patch.IsMbox(diff) {
+		mails := patch.SplitMbox(diff)
+		if len(mails) == 0 {
+			exitGenerate(exitNothingStaged, "There isn't a single patch in that mailbox.")
+		}
+		inputs := make([]generateInput, len(mails))
+		for i, mail := range mails {
+			inputs[i] = generateInput{
+				Source: fmt.Sprintf("%d/%d %s", i+1, len(mails), mail.Subject),
+				Diff:   mail.Diff,
+			}
+		}
+		return inputs, true
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		exitGenerate(exitNothingStaged, "That diff is empty, so there's nothing to talk about.")
+	}
+	return []generateInput{{Diff: diff}}, false
+}
+
+func newGenerateOutput(config *utils.Config, input generateInput, result llm.ChatResult[string]) generateOutput {
+	message := signMessage(config, result.Message, result.Model)
+	if input.Staged {
+		message = withSignature(config, result)
+	}
+	output := generateOutput{
+		Source:  input.Source,
+		Message: message,
+		Model:   result.Model,
+		Cost:    float64(result.Cost),
+		Tokens: generateTokens{
+			Prompt:     result.Usage.PromptTokens,
+			Cached:     result.Usage.CachedTokens,
+			Completion: result.Usage.CompletionTokens,
+		},
+		Footers:          []string{},
+		Violations:       lint.Lint(result.Message, configRules(config)),
+		PossibleBreaking: detectBreakingChanges(input.Diff),
+	}
+	if output.Violations == nil {
+		output.Violations = []lint.Violation{}
+	}
+
+	if m, err := conventional.Parse(message); err == nil {
+		output.Type = m.Type
+		output.Scope = m.Scope
+		output.Breaking = m.IsBreaking()
+		output.Subject = m.Description
+		output.Body = m.Body
+		for _, footer := range m.Footers {
+			output.Footers = append(output.Footers, footer.String())
+		}
+	} else {
+		subject, body, _ := strings.Cut(message, "\n")
+		output.Subject = subject
+		output.Body = strings.Trim(body, "\n")
+	}
+	return output
+}
+
+// printGenerated writes the messages to stdout. A series is printed with a
+// comment line naming each patch. JSON is always an array, so scripts needn't
+// care whether they were handed one patch or many.
+func printGenerated(outputs []generateOutput, series bool) {
+	if !GenerateJSON {
+		for i, output := range outputs {
+			if i > 0 {
+				fmt.Println()
+			}
+			if series {
+				fmt.Printf("# %s\n", output.Source)
+			}
+			fmt.Println(output.Message)
+			warnUnmarkedBreakingChanges(output)
+		}
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(outputs); err != nil {
+		exitGenerate(1, "Couldn't write the diagnosis: %v", err)
+	}
+}
+
+// warnUnmarkedBreakingChanges points out on stderr the suspected breaking
+// changes a message doesn't mark, since generate can't stop to ask.
+func warnUnmarkedBreakingChanges(output generateOutput) {
+	if output.Breaking || len(output.PossibleBreaking) == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "⚠️ Possible breaking changes the therapist didn't mark:")
+	for _, finding := range output.PossibleBreaking {
+		fmt.Fprintf(os.Stderr, "  - %s\n", finding)
+	}
+}
+
+var Print bool
+var GenerateJSON bool
+var GenerateDiff string
+var GenerateRange string
+
+func init() {
+	generateCmd.Flags().BoolVar(&GenerateJSON, "json", false, usageJSON)
+	generateCmd.Flags().StringVar(&GenerateDiff, "diff", "", usageGenerateDiff)
+	generateCmd.Flags().StringVar(&GenerateRange, "range", "", usageGenerateRange)
+
+	rootCmd.AddCommand(generateCmd)
+}
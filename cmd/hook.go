@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultHookTimeout = 15 * time.Second
+
+	usageHookTimeout = "Give up on the therapist after this long and leave the message to you (prepare-commit-msg only)"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "🪝 Bring therapy to plain `git commit`",
+	Long: `🪝 Kommit Hooks - Therapy for those who never book a session!
+
+Not everyone commits through ` + "`git kommit`" + `. Some of us commit from IDEs,
+others from muscle memory. These git hooks sneak a therapist into every plain
+` + "`git commit`" + ` by pre-filling the message buffer with a suggestion.
+
+Existing hooks are kept and still run first, and ` + "`core.hooksPath`" + ` is honoured.
+Merges, amends, rebases and ` + "`git commit -m`" + ` are left alone, and if the
+therapist is unreachable or slow, your commit carries on without them.`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:       "install [hook]",
+	Short:     "🪝 Install a kommit git hook (default: prepare-commit-msg)",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: utils.SupportedHooks,
+	Run:       runHookInstall,
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:       "uninstall [hook]",
+	Short:     "🪝 Remove a kommit git hook and restore the one it replaced",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: utils.SupportedHooks,
+	Run:       runHookUninstall,
+}
+
+var hookStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "🪝 Show which kommit git hooks are installed",
+	Args:  cobra.NoArgs,
+	Run:   runHookStatus,
+}
+
+var hookRunCmd = &cobra.Command{
+	Use:    "run <hook> [args...]",
+	Short:  "Run a kommit git hook (called by the hook scripts)",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	Run:    runHookRun,
+}
+
+func hookNameFromArgs(args []string) string {
+	if len(args) == 0 {
+		return utils.HookPrepareCommitMsg
+	}
+	name := args[0]
+	if !utils.IsSupportedHook(name) {
+		fmt.Printf("%s: Kommit doesn't offer a %q hook.\n", getErrorPrefix(HookCmd), name)
+		fmt.Printf("(Try one of: %s)\n", strings.Join(utils.SupportedHooks, ", "))
+		exit(1)
+	}
+	return name
+}
+
+func runHookInstall(cmd *cobra.Command, args []string) {
+	name := hookNameFromArgs(args)
+	if name != utils.HookPrepareCommitMsg && cmd.Flags().Changed("timeout") {
+		fmt.Printf("%s: The %s hook never calls the therapist, so --timeout doesn't apply.\n", getErrorPrefix(HookCmd), name)
+		exit(1)
+	}
+
+	status, err := utils.InstallHook(name, HookTimeout)
+	if err != nil {
+		fmt.Printf("%s: Failed to install the %s hook.\n", getErrorPrefix(HookCmd), name)
+		if Verbose {
+			log.Printf("Error installing hook: %v", err)
+		}
+		exit(1)
+	}
+
+	fmt.Printf("🪝 Therapy hook installed: %s\n", status.Path)
+	if status.Chained {
+		fmt.Printf("🤝 Your existing hook still runs first: %s%s\n", status.Path, utils.ChainedHookSuffix)
+	}
+}
+
+func runHookUninstall(cmd *cobra.Command, args []string) {
+	name := hookNameFromArgs(args)
+
+	status, err := utils.UninstallHook(name)
+	if err != nil {
+		fmt.Printf("%s: Failed to uninstall the %s hook.\n", getErrorPrefix(HookCmd), name)
+		if Verbose {
+			log.Printf("Error uninstalling hook: %v", err)
+		}
+		exit(1)
+	}
+
+	fmt.Printf("🪝 Therapy hook removed: %s\n", status.Path)
+	if status.Foreign {
+		fmt.Println("🤝 Your original hook is back in charge.")
+	}
+}
+
+func runHookStatus(cmd *cobra.Command, args []string) {
+	for _, name := range utils.SupportedHooks {
+		status, err := utils.GetHookStatus(name)
+		if err != nil {
+			fmt.Printf("%s: Failed to find your hooks.\n", getErrorPrefix(HookCmd))
+			if Verbose {
+				log.Printf("Error getting hook status: %v", err)
+			}
+			exit(1)
+		}
+
+		switch {
+		case status.Installed && status.Chained:
+			fmt.Printf("✅ %s: installed, chained with your existing hook (%s)\n", name, status.Path)
+		case status.Installed:
+			fmt.Printf("✅ %s: installed (%s)\n", name, status.Path)
+		case status.Foreign:
+			fmt.Printf("➖ %s: not installed, another hook is in place (%s)\n", name, status.Path)
+		default:
+			fmt.Printf("➖ %s: not installed\n", name)
+		}
+	}
+}
+
+// runHookRun is invoked by the hook scripts. Apart from a commit-msg lint
+// failure, it must never block a commit: every failure is logged (when
+// verbose) and swallowed.
+func runHookRun(cmd *cobra.Command, args []string) {
+	var err error
+	switch args[0] {
+	case utils.HookPrepareCommitMsg:
+		if os.Getenv(utils.HookSkipEnv) != "" {
+			return
+		}
+		err = runPrepareCommitMsgHook(args[1:])
+	case utils.HookCommitMsg:
+		err = runCommitMsgHook(args[1:])
+	}
+	if err != nil && Verbose {
+		log.Printf("kommit %s hook skipped: %v", args[0], err)
+	}
+}
+
+// runPrepareCommitMsgHook pre-fills the message file with a generated
+// message. Git passes the file, and optionally the message source and a
+// commit SHA. The whole hook runs against --timeout, not just the therapist:
+// loading the config, checking the budget and filing the bill can all wait on
+// files another kommit process holds.
+func runPrepareCommitMsgHook(args []string) error {
+	utils.CostLedgerLockWait = HookTimeout
+
+	done := make(chan error, 1)
+	go func() {
+		done <- prepareCommitMessage(args)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(HookTimeout):
+		return fmt.Errorf("timed out after %s", HookTimeout)
+	}
+}
+
+func prepareCommitMessage(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing commit message file")
+	}
+	messageFile := args[0]
+
+	// Only plain `git commit` (possibly with a commit.template) gets therapy;
+	// -m/-F, merges, squashes and amends already have a message
+	if len(args) > 1 && args[1] != "" && args[1] != "template" {
+		return nil
+	}
+	if utils.IsRebaseInProgress() {
+		return nil
+	}
+
+	existing, err := os.ReadFile(messageFile)
+	if err != nil {
+		return err
+	}
+	// A commit.template with a scaffold of its own is the user's to fill in;
+	// only its comments can go under a generated message
+	commentChar := utils.GetCommentChar()
+	if lint.CleanMessage(string(existing), commentChar) != "" {
+		return nil
+	}
+
+	diff, err := utils.ExecGit("diff", "--cached")
+	if err != nil || diff == "" {
+		return err
+	}
+
+	config, err := utils.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	report, err := utils.CheckBudget(config.Budget)
+	if err != nil {
+		return err
+	}
+	if len(report.Exceeded()) > 0 && !OverrideBudget {
+		return fmt.Errorf("therapy budget exhausted")
+	}
+
+	result, err := llm.GenerateCommitMessage(config, diff, "")
+	if err != nil {
+		return err
+	}
+	recordCost("hook", result)
+
+	// There's no time for another round trip under the hook's timeout
+	result.Message = tidyMessage(config, result.Message)
+
+	message := withSignature(config, result)
+	message += "\n" + breakingChangeComment(diff, message, commentChar) + string(existing)
+	// Atomically, since a timeout leaves no time to finish a partial write
+	return utils.WriteFileAtomic(messageFile, []byte(message), 0644)
+}
+
+// breakingChangeComment lists the suspected breaking changes the message
+// doesn't mark as comment lines, which git strips from the final message.
+// The hook can't stop to ask, so the editor is where the user decides.
+func breakingChangeComment(diff, message, char string) string {
+	findings := unmarkedBreakingChanges(diff, message)
+	if len(findings) == 0 {
+		return ""
+	}
+
+	comment := char + " Kommit suspects breaking changes. If they break users, add \"!\" and a\n"
+	comment += char + " \"BREAKING CHANGE:\" footer:\n"
+	for _, finding := range findings {
+		comment += char + "   - " + finding + "\n"
+	}
+	return comment + char + "\n"
+}
+
+var HookTimeout time.Duration
+
+func init() {
+	hookInstallCmd.Flags().DurationVar(&HookTimeout, "timeout", defaultHookTimeout, usageHookTimeout)
+	hookRunCmd.Flags().DurationVar(&HookTimeout, "timeout", defaultHookTimeout, usageHookTimeout)
+
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookCmd.AddCommand(hookStatusCmd)
+	hookCmd.AddCommand(hookRunCmd)
+	rootCmd.AddCommand(hookCmd)
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/cowboy-bebug/kommit/internal/llm"
 	"github.com/cowboy-bebug/kommit/internal/ui"
@@ -32,7 +31,7 @@ func runInit(cmd *cobra.Command, args []string) {
 	if config != nil {
 		fmt.Println("🥹 Your repo is already in therapy! Treatment plan exists.")
 		fmt.Println("🥰 Run `kommit commit` to continue the healing process!")
-		os.Exit(0)
+		exit(0)
 	}
 
 	// Get default config, if it doesn't exist
@@ -44,37 +43,39 @@ func runInit(cmd *cobra.Command, args []string) {
 			if Verbose {
 				log.Printf("Error getting default config: %v", err)
 			}
-			os.Exit(1)
+			exit(1)
 		}
 	}
 
 	// Select model
 	model, err := ui.SelectModel()
-	ui.HandleQuitError(err)
+	handleQuitError(err)
 	if err != nil {
 		fmt.Println("😰 Therapy session interrupted: Failed to select your therapist.")
 		if Verbose {
 			log.Printf("Error selecting model: %v", err)
 		}
-		os.Exit(1)
+		exit(1)
 	}
 	config.LLM.Model = model
 
 	// Select commit types
 	types, err := ui.SelectTypes()
-	ui.HandleQuitError(err)
+	handleQuitError(err)
 	if err != nil {
 		fmt.Println("😰 Therapy session interrupted: Failed to diagnose your repo's commit type preferences.")
 		if Verbose {
 			log.Printf("Error selecting commit types: %v", err)
 		}
-		os.Exit(1)
+		exit(1)
 	}
 
 	if len(types) > 0 {
 		config.Commit.Types = types
 	}
 
+	enforceBudget(InitCmd, config.Budget)
+
 	// Restart the spinner for the next operation
 	s := ui.Spinner("🤔 Analyzing your repo's commitment issues...")
 	s.Start()
@@ -94,7 +95,7 @@ func runInit(cmd *cobra.Command, args []string) {
 		if Verbose {
 			log.Printf("Error getting scopes from directory: %v", err)
 		}
-		os.Exit(1)
+		exit(1)
 	}
 
 	// Generate scopes from directory
@@ -104,9 +105,9 @@ func runInit(cmd *cobra.Command, args []string) {
 		if Verbose {
 			log.Printf("Error generating scopes from directory: %v", err)
 		}
-		os.Exit(1)
+		exit(1)
 	}
-	utils.UpdateCost(float64(result.Cost))
+	recordCost("init", result)
 
 	// Write config
 	config.Commit.Scopes = result.Message.Scopes
@@ -116,14 +117,14 @@ func runInit(cmd *cobra.Command, args []string) {
 		if Verbose {
 			log.Printf("Error writing config: %v", err)
 		}
-		os.Exit(1)
+		exit(1)
 	}
 
 	s.Stop()
 	fmt.Println("🥹 Your repo is in therapy! Treatment plan filled successfully.")
 	fmt.Println("🥰 Run `kommit commit` to continue the healing process!")
 	utils.PrintConfigFile()
-	os.Exit(0)
+	exit(0)
 }
 
 func init() {
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	lintFormatHuman = "human"
+	lintFormatJSON  = "json"
+
+	usageLintRange  = "Lint the messages of the commits in a range (e.g. origin/main..HEAD)"
+	usageLintFormat = "Print the diagnosis as human or json"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file|-]",
+	Short: "🩺 Check commit messages against your treatment plan",
+	Long: `🩺 Kommit Lint - A check-up for the messages you wrote yourself!
+
+This command examines commit messages against the types and scopes in your
+.kommitrc.yaml, along with the usual conventional commit hygiene: subject
+length, imperative mood, a blank line after the subject and wrapped bodies.
+
+Read a message from a file, from stdin, or from every commit in a range.
+It exits non-zero when the diagnosis is serious, which makes it a fine CI gate
+and an even better commit-msg hook (` + "`git kommit hook install commit-msg`" + `).`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runLint,
+}
+
+type lintResult struct {
+	Source     string           `json:"source"`
+	Subject    string           `json:"subject"`
+	Skipped    bool             `json:"skipped,omitempty"`
+	Violations []lint.Violation `json:"violations"`
+}
+
+// lintRules builds the rules from the repo config, falling back to the
+// default types when the repo hasn't started therapy yet.
+func lintRules() lint.Rules {
+	config, err := utils.LoadConfig()
+	HandleUnsupportedModelError(LintCmd, err)
+	return rulesOrDefault(config, err)
+}
+
+// rulesOrDefault builds the rules from a loaded config, or from the default
+// one when loading failed. Unlike lintRules, it never exits, which the
+// commit-msg hook relies on to fail open.
+func rulesOrDefault(config *utils.Config, err error) lint.Rules {
+	if err != nil {
+		if Verbose {
+			log.Printf("Error loading config, using default types: %v", err)
+		}
+		config, err = utils.GetDefaultConfig()
+		if err != nil {
+			return lint.DefaultRules(nil, nil)
+		}
+	}
+	return configRules(config)
+}
+
+func lintMessage(source, message, commentChar string, rules lint.Rules) lintResult {
+	message = lint.CleanMessage(message, commentChar)
+	subject, _, _ := strings.Cut(message, "\n")
+
+	result := lintResult{Source: source, Subject: subject, Violations: []lint.Violation{}}
+	if lint.IsGeneratedMessage(message) {
+		result.Skipped = true
+		return result
+	}
+	result.Violations = lint.Lint(message, rules)
+	return result
+}
+
+func readLintInput(args []string) (string, string, error) {
+	if len(args) == 0 || args[0] == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return "stdin", string(data), err
+	}
+	data, err := os.ReadFile(args[0])
+	return args[0], string(data), err
+}
+
+// collectLintResults gathers results from the range, or a file/stdin.
+func collectLintResults(args []string, rules lint.Rules) []lintResult {
+	if LintRange != "" {
+		commits, err := utils.GetCommitMessages(LintRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: Couldn't read the commits in %s.\n", getErrorPrefix(LintCmd), LintRange)
+			if Verbose {
+				log.Printf("Error reading commits: %v", err)
+			}
+			exit(1)
+		}
+
+		results := make([]lintResult, 0, len(commits))
+		for _, commit := range commits {
+			results = append(results, lintMessage(commit.Hash[:min(len(commit.Hash), 12)], commit.Message, lint.DefaultCommentChar, rules))
+		}
+		return results
+	}
+
+	source, message, err := readLintInput(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: Couldn't read the message to examine.\n", getErrorPrefix(LintCmd))
+		if Verbose {
+			log.Printf("Error reading message: %v", err)
+		}
+		exit(1)
+	}
+	return []lintResult{lintMessage(source, message, utils.GetCommentChar(), rules)}
+}
+
+func printLintResults(w io.Writer, results []lintResult) {
+	errorColor := color.New(color.FgRed, color.Bold)
+	warningColor := color.New(color.FgYellow)
+
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			fmt.Fprintf(w, "➖ %s: %s (skipped)\n", result.Source, result.Subject)
+			continue
+		case lint.HasErrors(result.Violations):
+			fmt.Fprintf(w, "❌ %s: %s\n", result.Source, result.Subject)
+		case len(result.Violations) > 0:
+			fmt.Fprintf(w, "⚠️  %s: %s\n", result.Source, result.Subject)
+		default:
+			fmt.Fprintf(w, "✅ %s: %s\n", result.Source, result.Subject)
+		}
+
+		for _, v := range result.Violations {
+			c := warningColor
+			if v.Severity == lint.SeverityError {
+				c = errorColor
+			}
+			fmt.Fprintf(w, "   %s %s\n", c.Sprintf("%s:", v.Severity), v)
+		}
+	}
+}
+
+func runLint(cmd *cobra.Command, args []string) {
+	if LintFormat != lintFormatHuman && LintFormat != lintFormatJSON {
+		fmt.Fprintf(os.Stderr, "%s: Can't print the diagnosis as %q.\n", getErrorPrefix(LintCmd), LintFormat)
+		fmt.Fprintln(os.Stderr, "(Try one of: human, json)")
+		exit(1)
+	}
+
+	// Nothing piped in and nothing named: waiting on stdin would look like a
+	// hang, so show how to book the check-up instead
+	if LintRange == "" && len(args) == 0 && ui.IsStdinTerminal() {
+		cmd.Usage()
+		exit(1)
+	}
+
+	results := collectLintResults(args, lintRules())
+
+	if LintFormat == lintFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+	} else {
+		printLintResults(os.Stdout, results)
+	}
+
+	for _, result := range results {
+		if lint.HasErrors(result.Violations) {
+			exit(1)
+		}
+	}
+}
+
+// runCommitMsgHook lints the message git is about to commit, rejecting the
+// commit on errors.
+func runCommitMsgHook(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing commit message file")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	result := lintMessage("commit message", string(data), utils.GetCommentChar(), rulesOrDefault(utils.LoadConfig()))
+	if !lint.HasErrors(result.Violations) {
+		return nil
+	}
+
+	printLintResults(os.Stderr, []lintResult{result})
+	fmt.Fprintf(os.Stderr, "%s: This message needs more therapy before it can be committed.\n", getErrorPrefix(LintCmd))
+	fmt.Fprintf(os.Stderr, "(Your message was kept in %s.)\n", args[0])
+	exit(utils.HookRejectExitCode)
+	return nil
+}
+
+var LintRange string
+var LintFormat string
+
+func init() {
+	lintCmd.Flags().StringVar(&LintRange, "range", "", usageLintRange)
+	lintCmd.Flags().StringVar(&LintFormat, "format", lintFormatHuman, usageLintFormat)
+
+	rootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/changelog"
+	"github.com/cowboy-bebug/kommit/internal/semver"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultTagPrefix      = "v"
+	defaultInitialVersion = "0.1.0"
+
+	// Exit code when no commit since the latest release calls for a new one
+	exitNothingToRelease = 2
+
+	usageNextVersionPre = "Make the next version a pre-release with this identifier, e.g. rc"
+	usageNextVersionTag = "Create an annotated tag for the next version, summarising the release"
+)
+
+var (
+	defaultMinorTypes = []string{"feat"}
+	defaultPatchTypes = []string{"fix", "perf"}
+)
+
+var nextVersionCmd = &cobra.Command{
+	Use:   "next-version",
+	Short: "🌱 Work out the next semantic version from your commits",
+	Long: `🌱 Kommit Next Version - Measuring how far your code has grown!
+
+This command finds the latest semver tag, reads the conventional commits since
+then and prints the next version: major for breaking changes, minor for
+features and patch for fixes and performance improvements. It exits with 2
+when nothing since the last release calls for a new one.
+
+Before the first stable release, the next version completes the latest
+pre-release, but only once something has been committed since. Until then,
+--pre bumps the pre-release number (1.0.0-rc.1 becomes 1.0.0-rc.2) and
+anything else exits with 2.
+
+Before 1.0.0, breaking changes bump the minor version by default. Pre-release
+and 0.x rules can be changed in .kommitrc.yaml, and --tag creates an annotated
+tag whose message summarises the release.`,
+	Args: cobra.NoArgs,
+	Run:  runNextVersion,
+}
+
+type releaseTag struct {
+	Name    string
+	Prefix  string
+	Version semver.Version
+}
+
+// getReleaseTags returns the semver tags reachable from HEAD. Without a
+// configured prefix, both "v1.2.3" and "1.2.3" count.
+func getReleaseTags(prefix string) ([]releaseTag, error) {
+	output, err := utils.ExecGit("tag", "--merged", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := []string{defaultTagPrefix, ""}
+	if prefix != "" {
+		prefixes = []string{prefix}
+	}
+
+	var tags []releaseTag
+	for _, name := range strings.Fields(output) {
+		for _, p := range prefixes {
+			if !strings.HasPrefix(name, p) {
+				continue
+			}
+			if version, err := semver.Parse(strings.TrimPrefix(name, p)); err == nil {
+				tags = append(tags, releaseTag{Name: name, Prefix: p, Version: version})
+				break
+			}
+		}
+	}
+
+	slices.SortFunc(tags, func(a, b releaseTag) int {
+		return semver.Compare(a.Version, b.Version)
+	})
+	return tags, nil
+}
+
+// bumpFor works out how much the entries call for, applying the 0.x rules.
+func bumpFor(entries []changelog.Entry, current semver.Version, config utils.VersionConfig) semver.Bump {
+	minorTypes := config.MinorTypes
+	if len(minorTypes) == 0 {
+		minorTypes = defaultMinorTypes
+	}
+	patchTypes := config.PatchTypes
+	if len(patchTypes) == 0 {
+		patchTypes = defaultPatchTypes
+	}
+
+	bump := semver.BumpNone
+	for _, entry := range entries {
+		switch {
+		case entry.Breaking:
+			bump = max(bump, semver.BumpMajor)
+		case slices.Contains(minorTypes, entry.Type):
+			bump = max(bump, semver.BumpMinor)
+		case slices.Contains(patchTypes, entry.Type):
+			bump = max(bump, semver.BumpPatch)
+		}
+	}
+
+	if current.Major == 0 {
+		switch {
+		case bump == semver.BumpMajor && config.PreMajorBreaking != "major":
+			bump = semver.BumpMinor
+		case bump == semver.BumpMinor && config.PreMajorFeatures == "patch":
+			bump = semver.BumpPatch
+		}
+	}
+	return bump
+}
+
+func runNextVersion(cmd *cobra.Command, args []string) {
+	if !utils.RevExists("HEAD") {
+		exitScript(NextVersionCmd, 1, "There's no history to grow from yet. Make your first commitment!")
+	}
+
+	config, err := utils.LoadConfig()
+	if err != nil {
+		HandleUnsupportedModelError(NextVersionCmd, err)
+		if Verbose {
+			log.Printf("Error loading config, using the default rules: %v", err)
+		}
+		if config, err = utils.GetDefaultConfig(); err != nil {
+			exitScript(NextVersionCmd, exitConfigMissing, "Couldn't load a treatment plan.")
+		}
+	}
+	rules := config.Version
+
+	prerelease := NextVersionPre
+	if prerelease == "" {
+		prerelease = rules.Prerelease
+	}
+
+	tags, err := getReleaseTags(rules.TagPrefix)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error listing tags: %v", err)
+		}
+		exitScript(NextVersionCmd, 1, "Couldn't read your tags.")
+	}
+
+	prefix := rules.TagPrefix
+	var latest, stable *releaseTag
+	for i := range tags {
+		latest = &tags[i]
+		if !tags[i].Version.IsPrerelease() {
+			stable = &tags[i]
+		}
+	}
+	if prefix == "" {
+		prefix = defaultTagPrefix
+		if latest != nil {
+			prefix = latest.Prefix
+		}
+	}
+
+	revisionRange := "HEAD"
+	if stable != nil {
+		revisionRange = stable.Name + "..HEAD"
+	}
+	commits, err := utils.GetCommitMessages(revisionRange)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error reading commits: %v", err)
+		}
+		exitScript(NextVersionCmd, 1, "Couldn't read the commits in %s.", revisionRange)
+	}
+	entries, _ := changelog.Entries(commits, config.Commit.References.Token)
+
+	var next semver.Version
+	switch {
+	case latest == nil:
+		initial := rules.Initial
+		if initial == "" {
+			initial = defaultInitialVersion
+		}
+		if next, err = semver.Parse(strings.TrimPrefix(initial, prefix)); err != nil {
+			exitScript(NextVersionCmd, 1, "The initial version %q in .kommitrc.yaml isn't a semantic version.", initial)
+		}
+	case stable == nil:
+		// Only pre-releases so far, which the next version completes unless
+		// something since the latest one breaks what it promised
+		next = latest.Version.Core()
+		since, err := utils.GetCommitMessages(latest.Name + "..HEAD")
+		if err != nil {
+			if Verbose {
+				log.Printf("Error reading commits: %v", err)
+			}
+			exitScript(NextVersionCmd, 1, "Couldn't read the commits since %s.", latest.Name)
+		}
+		// Nothing new only calls for another pre-release when asked for one
+		if len(since) == 0 && NextVersionPre == "" {
+			exitScript(NextVersionCmd, exitNothingToRelease, "Nothing since %s calls for a new release. Growth takes time!", latest.Name)
+		}
+		sinceEntries, _ := changelog.Entries(since, config.Commit.References.Token)
+		if bump := bumpFor(sinceEntries, next, rules); bump == semver.BumpMajor {
+			next = next.Bump(bump)
+			if Verbose {
+				log.Printf("%s bump since %s", bump, latest.Name)
+			}
+		}
+	default:
+		bump := bumpFor(entries, stable.Version, rules)
+		if bump == semver.BumpNone {
+			exitScript(NextVersionCmd, exitNothingToRelease, "Nothing since %s calls for a new release. Growth takes time!", stable.Name)
+		}
+		next = stable.Version.Bump(bump)
+		// A pre-release that already aimed higher sets the target
+		if latest.Version.IsPrerelease() && semver.Compare(latest.Version.Core(), next) > 0 {
+			next = latest.Version.Core()
+		}
+		if Verbose {
+			log.Printf("%s bump since %s", bump, stable.Name)
+		}
+	}
+	if prerelease != "" {
+		var previous semver.Version
+		if latest != nil {
+			previous = latest.Version
+		}
+		next = next.WithPrerelease(prerelease, previous)
+	}
+
+	if NextVersionTag {
+		tagRelease(config, prefix+next.String(), entries)
+	}
+	fmt.Println(next)
+}
+
+// tagRelease creates an annotated tag whose message lists the release's
+// changes.
+func tagRelease(config *utils.Config, name string, entries []changelog.Entry) {
+	if utils.RevExists("refs/tags/" + name) {
+		exitScript(NextVersionCmd, 1, "The tag %s already exists.", name)
+	}
+
+	release := changelog.Build(name, "", entries, config.Commit.Changelog)
+	message := release.Text(config.Commit.Changelog.BreakingTitle)
+
+	if _, err := utils.ExecGit("tag", "--annotate", "--cleanup=verbatim", "--message", message, name); err != nil {
+		if Verbose {
+			log.Printf("Error creating tag: %v", err)
+		}
+		exitScript(NextVersionCmd, 1, "Couldn't create the tag %s.", name)
+	}
+
+	fmt.Fprintf(os.Stderr, "🌱 Tagged %s. Push it with `git push origin %s` when you're ready to share.\n", name, name)
+}
+
+var NextVersionPre string
+var NextVersionTag bool
+
+func init() {
+	nextVersionCmd.Flags().StringVar(&NextVersionPre, "pre", "", usageNextVersionPre)
+	nextVersionCmd.Flags().BoolVar(&NextVersionTag, "tag", false, usageNextVersionTag)
+
+	rootCmd.AddCommand(nextVersionCmd)
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// The combined diff is trimmed to this many lines for the prompt
+	maxPRDiffLines = 2000
+
+	usagePROutput     = "Write the description to a file and print only the title (- prints only the description)"
+	usagePRTemplate   = "Fill in this pull request template (default: the repo's .github/pull_request_template.md)"
+	usagePRNoTemplate = "Ignore the repo's pull request template"
+	usagePRJSON       = "Print the title and description as JSON, with the model, cost and tokens"
+	usagePRBase       = "The branch you're merging into (default: the branch's upstream if it tracks another branch, else the default branch)"
+)
+
+// Where GitHub looks for a pull request template, relative to the repo root
+var pullRequestTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+}
+
+var prCmd = &cobra.Command{
+	Use:   "pr [base]",
+	Short: "📣 Write a pull request title and description for your branch",
+	Long: `📣 Kommit PR - Present your branch's case to the group!
+
+This command looks at every commit on your branch since it forked from base
+(the branch it tracks, if that isn't its own remote copy, or else the default
+branch of your upstream or origin remote, unless you say otherwise) along with
+their combined diff, and writes a pull request title
+and a markdown description: a summary, the changes, testing notes and any
+breaking changes. If the repo has a pull request template, the therapist
+fills that in instead.
+
+It only talks to your therapist, never to GitHub, so pipe it wherever you like:
+
+  gh pr create --title "$(git kommit pr -o pr.md)" --body-file pr.md`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPR,
+}
+
+type prOutput struct {
+	Title  string         `json:"title"`
+	Body   string         `json:"body"`
+	Model  string         `json:"model"`
+	Cost   float64        `json:"cost"`
+	Tokens generateTokens `json:"tokens"`
+}
+
+func runPR(cmd *cobra.Command, args []string) {
+	base := PRBase
+	if len(args) > 0 {
+		if base != "" && base != args[0] {
+			exitScript(PrCmd, 1, "Merging into %s or %s? Name the base only once.", base, args[0])
+		}
+		base = args[0]
+	}
+	if base == "" {
+		base = utils.GetUpstreamBase()
+	}
+	if base == "" {
+		var err error
+		base, err = utils.GetDefaultBase()
+		if err != nil {
+			exitScript(PrCmd, 1, "Couldn't tell which branch you're merging into. Pass it along: `git kommit pr --base main`.")
+		}
+	}
+	if Verbose {
+		log.Printf("Comparing against %s", base)
+	}
+
+	mergeBase, err := utils.GetMergeBase(base)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error finding merge base: %v", err)
+		}
+		exitScript(PrCmd, 1, "Your branch and %s have nothing in common.", base)
+	}
+
+	commits, err := utils.GetCommitMessages(mergeBase + "..HEAD")
+	if err != nil || len(commits) == 0 {
+		if Verbose && err != nil {
+			log.Printf("Error reading commits: %v", err)
+		}
+		exitScript(PrCmd, exitNothingStaged, "Your branch has no commits of its own since %s.", base)
+	}
+
+	diff, err := utils.ExecGit("diff", mergeBase, "HEAD")
+	if err != nil {
+		if Verbose {
+			log.Printf("Error getting diff: %v", err)
+		}
+		exitScript(PrCmd, 1, "Couldn't read your branch's changes.")
+	}
+
+	template := readPullRequestTemplate()
+
+	config := loadScriptConfig(PrCmd)
+	enforceScriptBudget(PrCmd, config.Budget)
+
+	messages := make([]string, len(commits))
+	for i, commit := range commits {
+		messages[i] = commit.Message
+	}
+	diff = trimLines(diff, maxPRDiffLines)
+
+	s := ui.Spinner("🧐 Preparing your branch's case for the group...")
+	s.Start()
+	var output prOutput
+	if template != "" {
+		result, err := llm.FillPullRequestTemplate(config.LLM.Model, messages, diff, Message, template)
+		s.Stop()
+		if err != nil {
+			exitProviderFailure(PrCmd, err)
+		}
+		recordCost("pr", result)
+		output = newPROutput(result, result.Message.Title, result.Message.Body)
+	} else {
+		result, err := llm.GeneratePullRequest(config.LLM.Model, messages, diff, Message)
+		s.Stop()
+		if err != nil {
+			exitProviderFailure(PrCmd, err)
+		}
+		recordCost("pr", result)
+		output = newPROutput(result, result.Message.Title, renderPullRequest(result.Message))
+	}
+
+	printPR(output)
+}
+
+func newPROutput[T any](result llm.ChatResult[T], title, body string) prOutput {
+	return prOutput{
+		Title: strings.TrimSpace(title),
+		Body:  strings.TrimSpace(body) + "\n",
+		Model: result.Model,
+		Cost:  float64(result.Cost),
+		Tokens: generateTokens{
+			Prompt:     result.Usage.PromptTokens,
+			Cached:     result.Usage.CachedTokens,
+			Completion: result.Usage.CompletionTokens,
+		},
+	}
+}
+
+// readPullRequestTemplate returns the template to fill in, if any.
+func readPullRequestTemplate() string {
+	if PRNoTemplate {
+		return ""
+	}
+
+	if PRTemplate != "" {
+		data, err := os.ReadFile(PRTemplate)
+		if err != nil {
+			if Verbose {
+				log.Printf("Error reading template: %v", err)
+			}
+			exitScript(PrCmd, 1, "Couldn't read the template %s.", PRTemplate)
+		}
+		return string(data)
+	}
+
+	root, err := utils.GetConfigPath()
+	if err != nil {
+		return ""
+	}
+	for _, path := range pullRequestTemplatePaths {
+		if data, err := os.ReadFile(filepath.Join(root, path)); err == nil {
+			return string(data)
+		}
+	}
+	return ""
+}
+
+// renderPullRequest turns the sections into a markdown description, leaving
+// out the breaking changes when there are none.
+func renderPullRequest(pr llm.PullRequest) string {
+	var b strings.Builder
+	b.WriteString("## Summary\n\n" + strings.TrimSpace(pr.Summary) + "\n")
+
+	if len(pr.Changes) > 0 {
+		b.WriteString("\n## Changes\n\n")
+		for _, change := range pr.Changes {
+			b.WriteString("- " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(change), "- ")) + "\n")
+		}
+	}
+
+	if testing := strings.TrimSpace(pr.Testing); testing != "" {
+		b.WriteString("\n## Testing\n\n" + testing + "\n")
+	}
+
+	if breaking := strings.TrimSpace(pr.BreakingChanges); breaking != "" {
+		b.WriteString("\n## Breaking Changes\n\n" + breaking + "\n")
+	}
+
+	return b.String()
+}
+
+func printPR(output prOutput) {
+	if PRJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			exitScript(PrCmd, 1, "Couldn't write the case notes: %v", err)
+		}
+		return
+	}
+
+	switch PROutput {
+	case "":
+		fmt.Printf("%s\n\n%s", output.Title, output.Body)
+	case "-":
+		fmt.Print(output.Body)
+	default:
+		if err := os.WriteFile(PROutput, []byte(output.Body), 0644); err != nil {
+			if Verbose {
+				log.Printf("Error writing description: %v", err)
+			}
+			exitScript(PrCmd, 1, "Couldn't write the description to %s.", PROutput)
+		}
+		fmt.Println(output.Title)
+	}
+}
+
+// trimLines keeps the first limit lines of text, noting how many were dropped.
+func trimLines(text string, limit int) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) <= limit {
+		return text
+	}
+	return strings.Join(lines[:limit], "\n") + fmt.Sprintf("\n... (%d more lines)\n", len(lines)-limit)
+}
+
+var PROutput string
+var PRTemplate string
+var PRNoTemplate bool
+var PRJSON bool
+var PRBase string
+
+func init() {
+	prCmd.Flags().StringVarP(&PROutput, "output", "o", "", usagePROutput)
+	prCmd.Flags().StringVar(&PRTemplate, "template", "", usagePRTemplate)
+	prCmd.Flags().BoolVar(&PRNoTemplate, "no-template", false, usagePRNoTemplate)
+	prCmd.Flags().BoolVar(&PRJSON, "json", false, usagePRJSON)
+	prCmd.Flags().StringVar(&PRBase, "base", "", usagePRBase)
+
+	rootCmd.AddCommand(prCmd)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const usageRewordForce = "Reword even if some of the commits have already been pushed"
+
+var rewordCmd = &cobra.Command{
+	Use:   "reword <range>",
+	Short: "🗂️  Rewrite the messages of a range of commits",
+	Long: `🗂️  Kommit Reword - Group therapy for a whole branch of "wip" commits!
+
+This command diagnoses each commit in a range (e.g. origin/main..HEAD, or
+just origin/main for everything since) from its own diff and suggests a proper
+conventional message. Review the suggestions, accepting, editing or keeping
+each one, and kommit rewrites the history for you - no interactive rebase
+required.
+
+Authorship, dates and trailers are preserved, and a backup ref is kept so the
+rewrite can be undone. Commits that have already been pushed are left alone
+unless you pass --force.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runReword,
+}
+
+func exitReword(format string, args ...any) {
+	fmt.Printf("%s: %s\n", getErrorPrefix(RewordCmd), fmt.Sprintf(format, args...))
+	exit(1)
+}
+
+func runReword(cmd *cobra.Command, args []string) {
+	revisionRange := args[0]
+	if !strings.Contains(revisionRange, "..") {
+		revisionRange += "..HEAD"
+	}
+
+	if utils.IsRebaseInProgress() {
+		exitReword("Finish your rebase before starting group therapy.")
+	}
+
+	head, err := utils.ExecGit("rev-parse", "--verify", "HEAD")
+	if err != nil {
+		exitReword("There are no commits to reword yet.")
+	}
+	head = strings.TrimSpace(head)
+
+	hashes, err := utils.ListCommits(revisionRange)
+	if err != nil || len(hashes) == 0 {
+		if Verbose && err != nil {
+			log.Printf("Error listing commits: %v", err)
+		}
+		exitReword("No commits found in %s.", revisionRange)
+	}
+
+	for _, hash := range hashes {
+		if _, err := utils.ExecGit("merge-base", "--is-ancestor", hash, head); err != nil {
+			exitReword("Commit %.7s isn't part of your current branch.", hash)
+		}
+
+		remotes, err := utils.GetRemoteBranchesContaining(hash)
+		if err != nil && Verbose {
+			log.Printf("Error checking remote branches: %v", err)
+		}
+		if len(remotes) > 0 && !RewordForce {
+			fmt.Printf("%s: Commit %.7s has already been shared with %s.\n", getErrorPrefix(RewordCmd), hash, strings.Join(remotes, ", "))
+			fmt.Println("(Rewriting it means a force push. Pass --force if you really mean it.)")
+			exit(1)
+		}
+	}
+
+	config := loadConfig(RewordCmd)
+	rules := configRules(config)
+
+	items, commits := proposeRewords(config, hashes)
+	if len(items) == 0 {
+		exitReword("Only merge commits in %s, and those speak for themselves.", revisionRange)
+	}
+
+	if !Approve {
+		items, err = ui.SelectRewordActions(items)
+		handleQuitError(err)
+		if err != nil {
+			log.Printf("Error reviewing messages: %v", err)
+			exit(1)
+		}
+	}
+
+	messages := make(map[string]string)
+	for _, item := range items {
+		commit := commits[item.Hash]
+		message := item.Proposed
+
+		switch item.Action {
+		case ui.RewordActionKeep:
+			continue
+		case ui.RewordActionEdit:
+			edited, err := utils.EditMessage(message)
+			if err != nil {
+				exitReword("Your self-therapy session for %s was interrupted!", commit.ShortHash())
+			}
+			message = lint.CleanMessage(edited, lint.DefaultCommentChar)
+			if message == "" {
+				continue
+			}
+		}
+
+		if violations := lint.Lint(message, rules); len(violations) > 0 {
+			fmt.Printf("⚠️  %s: %s\n", commit.ShortHash(), strings.SplitN(message, "\n", 2)[0])
+			printViolations(violations)
+		}
+		messages[commit.Hash] = message
+	}
+
+	if len(messages) == 0 {
+		fmt.Println("🧐 Every commit keeps its old message. Call if your commitment issues return!")
+		exit(0)
+	}
+
+	backupRef, err := utils.CreateBackupRef("reword", head)
+	if err != nil {
+		exitReword("Couldn't keep a backup of your history, so it stays untouched.")
+	}
+
+	newHead, err := utils.RewriteMessages(head, messages)
+	if err == nil {
+		err = utils.UpdateHead(head, newHead, "reword "+revisionRange)
+	}
+	if err != nil {
+		if Verbose {
+			log.Printf("Error rewriting history: %v", err)
+		}
+		exitReword("Failed to rewrite your history. Nothing was changed.")
+	}
+
+	fmt.Printf("🎓 Reworded %d commit(s)! Your history finally makes sense.\n", len(messages))
+	fmt.Printf("(Changed your mind? `git reset --soft %s` undoes it.)\n", backupRef)
+}
+
+// proposeRewords generates a message for every non-merge commit, keeping the
+// original trailers.
+func proposeRewords(config *utils.Config, hashes []string) ([]ui.RewordItem, map[string]utils.CommitInfo) {
+	var items []ui.RewordItem
+	commits := make(map[string]utils.CommitInfo)
+
+	for _, hash := range hashes {
+		commit, err := utils.GetCommitInfo(hash)
+		if err != nil {
+			exitReword("Couldn't read commit %.7s.", hash)
+		}
+		if commit.IsMerge() {
+			continue
+		}
+
+		diff, err := utils.GetDiff(commit)
+		if err != nil || diff == "" {
+			continue
+		}
+
+		fmt.Printf("🗂️  %s %s\n", commit.ShortHash(), commit.Subject())
+		result := generateCommitMessage(RewordCmd, config, diff, "reword")
+
+		proposed, err := utils.PreserveTrailers(commit.Message, withSignature(config, result))
+		if err != nil {
+			if Verbose {
+				log.Printf("Error preserving trailers: %v", err)
+			}
+			proposed = withSignature(config, result)
+		}
+
+		commits[commit.ShortHash()] = commit
+		items = append(items, ui.RewordItem{
+			Hash:     commit.ShortHash(),
+			Subject:  commit.Subject(),
+			Proposed: proposed,
+			Action:   ui.RewordActionAccept,
+		})
+	}
+
+	return items, commits
+}
+
+var RewordForce bool
+
+func init() {
+	rewordCmd.Flags().BoolVarP(&RewordForce, "force", "f", false, usageRewordForce)
+
+	rootCmd.AddCommand(rewordCmd)
+}
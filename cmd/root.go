@@ -1,27 +1,26 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
-	"log"
-	"os"
-	"os/exec"
 
-	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/lint"
 	"github.com/cowboy-bebug/kommit/internal/ui"
 	"github.com/cowboy-bebug/kommit/internal/utils"
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 const (
-	commitMessageSignature = "[Generated by Kommit]"
-
 	usageMessage = "Provide your side of the story before the AI therapist diagnoses your code changes"
 	usageApprove = "Skip the therapy session to approve the suggested message"
 	usageEdit    = "Skip the therapy session to edit the suggested message"
 	usageHelp    = "Schedule an emergency therapy session (show help)"
 	usageVerbose = "Hear all the relationship details your repo normally keeps private"
+
+	usageOverrideBudget = "Book the session even if your therapy budget is exhausted"
+
+	usageAll              = "Stage all modified and deleted tracked files first, like git commit -a"
+	usageIncludeUntracked = "Like --all, but bring untracked files to the session too"
+	usagePatch            = "Pick the hunks to stage before the session, like git add -p"
 )
 
 var rootCmd = &cobra.Command{
@@ -45,159 +44,64 @@ expressive documentation of your development journey.`,
 	Run: runCommit,
 }
 
-func runCommit(cmd *cobra.Command, args []string) {
-	// Check if there are staged changes
-	diff, err := utils.ExecGit("diff", "--cached")
-	if err != nil || diff == "" {
-		fmt.Println("😰 Commitment issues detected: You're not ready to commit... anything.")
-		fmt.Println("(Stage some changes first!)")
-		os.Exit(1)
-	}
+// indexBeforeStaging is the index as it was before --all or --patch staged
+// anything. It is put back whenever kommit exits without committing, so
+// walking away leaves the index untouched, as with an aborted
+// `git commit -a`.
+var indexBeforeStaging *utils.IndexBackup
 
-	// Load config to get available scopes
-	config, err := utils.LoadConfig()
-	if err != nil {
-		HandleUnsupportedModelError(RootCmd, err)
-		fmt.Println("😰 Commitment issues detected: You haven't booked your first therapy session!")
-		fmt.Println("(Run 'git kommit init' to get on the calendar.)")
-		if Verbose {
-			log.Printf("Error loading config: %v", err)
+func runCommit(cmd *cobra.Command, args []string) {
+	if Print || GenerateJSON {
+		if All || IncludeUntracked || Patch {
+			exitGenerate(1, "--print only reads what's already staged, so it doesn't mix with --all or --patch.")
 		}
-		os.Exit(1)
+		runGenerate(cmd, args)
+		return
 	}
 
-	context := "I'm using the following conventional commit types:\n"
-	context += fmt.Sprintf("- types: %s\n", config.Commit.Types)
-	context += "Optionally use the following scopes only if the changes are related to the scopes:\n"
-	context += fmt.Sprintf("- scopes: %s\n", config.Commit.Scopes)
-
-	s := ui.Spinner("🧐 Helping your code express its feelings to future developers...")
-	s.Start()
-	result, err := llm.GenerateCommitMessage(config, diff, Message)
-	utils.UpdateCost(float64(result.Cost))
-	s.Stop()
-	if err != nil {
-		fmt.Println("😰 Commitment issues detected: Your code is experiencing emotional resistance!")
-		if errors.Is(err, &llm.APIKeyMissingError{}) {
-			fmt.Println("\nHave you set up your OpenAI API key? Try one of these:")
-			fmt.Println("  export OPENAI_API_KEY=\"sk-...\"")
-			fmt.Println("  export KOMMIT_OPENAI_API_KEY=\"sk-...\"    # For a dedicated key")
+	if indexBeforeStaging == nil {
+		switch {
+		case Patch && (All || IncludeUntracked):
+			fmt.Println("😰 Commitment issues detected: --patch and --all are mixed signals.")
+			fmt.Println("(Pick hunks with --patch, or take everything with --all.)")
+			exit(1)
+		case Patch:
+			stagePatch()
+		case All || IncludeUntracked:
+			stageAll()
 		}
-		if Verbose {
-			log.Printf("Error generating commit message: %v", err)
-		}
-		os.Exit(1)
 	}
 
-	commitMessage := result.Message
-	commitMessage += fmt.Sprintf("\n\n%s", commitMessageSignature)
-
-	var option ui.CommitOption
-	if Approve {
-		option = ui.CommitOptionProceed
-	} else if Edit {
-		option = ui.CommitOptionEdit
-	} else {
-		fmt.Println("💭 Your therapist's recommendation:")
-		fmt.Println("```text")
-		color.New(color.FgGreen, color.Bold).Println(commitMessage)
-		fmt.Println("```")
-
-		option, err = ui.SelectCommit()
-		ui.HandleQuitError(err)
-		if err != nil {
-			log.Printf("Error confirming commit: %v", err)
-			os.Exit(1)
-		}
+	// Check if there are staged changes
+	diff, err := utils.ExecGit("diff", "--cached")
+	if err != nil || diff == "" {
+		fmt.Println("😰 Commitment issues detected: You're not ready to commit... anything.")
+		fmt.Println("(Stage some changes first!)")
+		exit(1)
 	}
 
-	switch option {
-	case ui.CommitOptionProceed:
-		fmt.Println("🧐 Preparing for your code's commitment ceremony...")
-
-		tempFile, err := os.CreateTemp("", ".kommit-msg-*.txt")
-		if err != nil {
-			fmt.Println("😰 Commitment issues detected: Refusing to prepare temporary paperwork!")
-			if Verbose {
-				log.Printf("Error creating temp file: %v", err)
-			}
-			os.Exit(1)
-		}
-		tempFilePath := tempFile.Name()
-		defer os.Remove(tempFilePath) // Clean up the temp file when done
-
-		if _, err := tempFile.WriteString(commitMessage); err != nil {
-			tempFile.Close()
-			fmt.Println("😰 Commitment issues detected: Refusing to fill the temporary paperwork!")
-			if Verbose {
-				log.Printf("Error writing to temp file: %v", err)
-			}
-			os.Exit(1)
-		}
-		tempFile.Close()
-
-		cmd := exec.Command("git", "commit", "-q", "-F", tempFilePath)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		err = cmd.Run()
-		if err != nil {
-			fmt.Println("😰 Commitment issues detected: Refusing to commit!")
-			if Verbose {
-				log.Printf("Error committing: %v", err)
-			}
-			os.Exit(1)
-		}
-		fmt.Println("🧐 Successfully committed! Your relationship with the repo has deepened!")
-	case ui.CommitOptionEdit:
-		fmt.Println("🧐 Starting your self-guided therapy session...")
-
-		tempFile, err := os.CreateTemp("", ".kommit-msg-*.txt")
-		if err != nil {
-			fmt.Println("😰 Commitment issues detected: Couldn't prepare your self-therapy materials!")
-			if Verbose {
-				log.Printf("Error creating temp file: %v", err)
-			}
-			os.Exit(1)
-		}
-		tempFilePath := tempFile.Name()
-		defer os.Remove(tempFilePath)
-
-		// Write the suggested message to the file as a starting point
-		if _, err := tempFile.WriteString(commitMessage); err != nil {
-			tempFile.Close()
-			fmt.Println("😰 Commitment issues detected: Couldn't write your therapy starting notes!")
-			if Verbose {
-				log.Printf("Error writing to temp file: %v", err)
-			}
-			os.Exit(1)
-		}
-		tempFile.Close()
+	if !Patch {
+		warnPartiallyStaged()
+	}
 
-		// Set the GIT_EDITOR environment variable to open the editor with the file
-		cmd := exec.Command("git", "commit", "--template", tempFilePath, "--allow-empty-message")
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	// Load config to get available scopes
+	config := loadConfig(RootCmd)
 
-		fmt.Println("📝 Opening your personal therapy journal (editor)...")
+	result := generateCommitMessage(RootCmd, config, diff, "commit")
+	result.Message = reviewBreakingChange(diff, result.Message)
 
-		err = cmd.Run()
-		if err != nil {
-			fmt.Println("😰 Commitment issues detected: Your self-therapy session was interrupted!")
-			if Verbose {
-				log.Printf("Error during commit: %v", err)
-			}
-			os.Exit(1)
-		}
+	commitMessage := withSignature(config, result)
+	violations := lint.Lint(result.Message, configRules(config))
 
-		fmt.Println("🎓 Self-therapy complete! You've committed to your own path of growth.")
+	option := reviewCommitMessage(commitMessage, violations)
+	switch option {
+	case ui.CommitOptionProceed, ui.CommitOptionEdit:
+		commitWithMessage(RootCmd, option, commitMessage, false)
 	case ui.CommitOptionRerun:
 		runCommit(cmd, args)
 	case ui.CommitOptionExit:
 		fmt.Println("🧐 You're on your own path now. Call if your commitment issues return!")
-		os.Exit(0)
+		exit(0)
 	}
 }
 
@@ -206,6 +110,10 @@ var Approve bool
 var Edit bool
 var Verbose bool
 var Debug bool
+var OverrideBudget bool
+var All bool
+var IncludeUntracked bool
+var Patch bool
 
 func init() {
 	rootCmd.SetHelpCommand(&cobra.Command{
@@ -215,7 +123,7 @@ func init() {
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				rootCmd.Help()
-				os.Exit(0)
+				exit(0)
 			}
 		},
 	})
@@ -224,6 +132,14 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&Approve, "approve", "a", false, usageApprove)
 	rootCmd.PersistentFlags().BoolVarP(&Edit, "edit", "e", false, usageEdit)
 	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, usageVerbose)
+	rootCmd.PersistentFlags().BoolVar(&OverrideBudget, "override-budget", false, usageOverrideBudget)
+
+	rootCmd.Flags().BoolVarP(&All, "all", "A", false, usageAll)
+	rootCmd.Flags().BoolVar(&IncludeUntracked, "include-untracked", false, usageIncludeUntracked)
+	rootCmd.Flags().BoolVarP(&Patch, "patch", "p", false, usagePatch)
+	rootCmd.Flags().BoolVar(&Print, "print", false, usagePrint)
+	rootCmd.Flags().BoolVar(&GenerateJSON, "json", false, usagePrintJSON)
+	addGitCommitFlags(rootCmd)
 
 	rootCmd.PersistentFlags().BoolP("help", "h", false, usageHelp) // TODO: add a man page
 }
@@ -231,6 +147,6 @@ func init() {
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
 }
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/patch"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	splitByIntent = "intent"
+	splitByScope  = "scope"
+	splitByFile   = "file"
+
+	// Hunks are trimmed to this many lines when asking for groups
+	maxSplitPromptLines = 60
+
+	usageSplitBy = "Group hunks by intent (asks the therapist), scope or file"
+)
+
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "✂️  Split your staged changes into atomic commits",
+	Long: `✂️  Kommit Split - For changes that have been bottling everything up!
+
+This command breaks your staged changes down hunk by hunk and proposes how to
+group them into separate, atomic commits - by intent (asking the therapist),
+by scope from your .kommitrc.yaml, or by file. Move hunks between commits
+until the grouping feels right, and kommit writes a message for each group
+and commits them one after another.
+
+If anything goes wrong along the way, your branch and index are put back
+exactly as they were.`,
+	Run: runSplit,
+}
+
+func exitSplit(format string, args ...any) {
+	fmt.Printf("%s: %s\n", getErrorPrefix(SplitCmd), fmt.Sprintf(format, args...))
+	exit(1)
+}
+
+func runSplit(cmd *cobra.Command, args []string) {
+	if !slices.Contains([]string{splitByIntent, splitByScope, splitByFile}, SplitBy) {
+		exitSplit("Unknown grouping %q. Try intent, scope or file.", SplitBy)
+	}
+
+	if !utils.RevExists("HEAD") {
+		fmt.Printf("%s: Your repo needs a first commitment before it can split into more.\n", getErrorPrefix(SplitCmd))
+		fmt.Println("(Run `git kommit` to make your first commitment!)")
+		exit(1)
+	}
+
+	diff, err := utils.ExecGit("diff", "--cached", "--binary")
+	if err != nil || diff == "" {
+		fmt.Printf("%s: You're not ready to commit... anything.\n", getErrorPrefix(SplitCmd))
+		fmt.Println("(Stage some changes first!)")
+		exit(1)
+	}
+
+	files, err := patch.Parse(diff)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error parsing diff: %v", err)
+		}
+		exitSplit("Your staged changes are too tangled to read.")
+	}
+
+	units := patch.Units(files)
+	if len(units) < 2 {
+		exitSplit("There's only one hunk staged, so there's nothing to split.\n(Run `git kommit` instead!)")
+	}
+
+	config := loadConfig(SplitCmd)
+
+	var groups []patch.Group
+	switch SplitBy {
+	case splitByIntent:
+		groups = groupByIntent(config, units)
+	case splitByScope:
+		groups = patch.GroupByScope(units, config.Commit.Scopes)
+	case splitByFile:
+		groups = patch.GroupByFile(units)
+	}
+
+	if !Approve {
+		groups, err = ui.SelectSplitGroups(groups)
+		handleQuitError(err)
+		if err != nil {
+			log.Printf("Error grouping hunks: %v", err)
+			exit(1)
+		}
+	}
+
+	if len(groups) < 2 {
+		fmt.Println("🧐 Everything belongs together after all. Run `git kommit` for a single commit!")
+		exit(0)
+	}
+
+	patches := patch.Series(groups)
+	messages := proposeSplitMessages(config, groups, patches)
+
+	applySplit(patches, messages)
+}
+
+// groupByIntent asks the therapist to group the hunks, falling back to
+// scopes when that fails.
+func groupByIntent(config *utils.Config, units []patch.Unit) []patch.Group {
+	enforceBudget(SplitCmd, config.Budget)
+
+	hunks := make([]string, len(units))
+	for i, unit := range units {
+		lines := strings.Split(strings.TrimSuffix(unit.Diff(), "\n"), "\n")
+		if len(lines) > maxSplitPromptLines {
+			lines = append(lines[:maxSplitPromptLines], "...")
+		}
+		hunks[i] = strings.Join(lines, "\n")
+	}
+
+	s := ui.Spinner("🧐 Untangling your changes into separate feelings...")
+	s.Start()
+	result, err := llm.GenerateHunkGroups(config.LLM.Model, hunks, config.Commit.Scopes)
+	s.Stop()
+	if err != nil {
+		fmt.Println("⚠️  Your therapist couldn't untangle the changes, so they're grouped by scope instead.")
+		if Verbose {
+			log.Printf("Error grouping hunks: %v", err)
+		}
+		return patch.GroupByScope(units, config.Commit.Scopes)
+	}
+	recordCost("split", result)
+
+	// Every hunk goes in exactly one group, whatever the model says
+	assigned := make([]bool, len(units))
+	var groups []patch.Group
+	for _, hunkGroup := range result.Message.Groups {
+		group := patch.Group{Title: hunkGroup.Title}
+		slices.Sort(hunkGroup.Hunks)
+		for _, i := range hunkGroup.Hunks {
+			if i < 0 || i >= len(units) || assigned[i] {
+				continue
+			}
+			assigned[i] = true
+			group.Units = append(group.Units, units[i])
+		}
+		if len(group.Units) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	leftovers := patch.Group{Title: "Everything else"}
+	for i, unit := range units {
+		if !assigned[i] {
+			leftovers.Units = append(leftovers.Units, unit)
+		}
+	}
+	if len(leftovers.Units) > 0 {
+		groups = append(groups, leftovers)
+	}
+
+	return groups
+}
+
+// proposeSplitMessages generates and reviews a message for every group,
+// exiting if the user walks away.
+func proposeSplitMessages(config *utils.Config, groups []patch.Group, patches []string) []string {
+	rules := configRules(config)
+
+	messages := make([]string, len(groups))
+	for i, group := range groups {
+		fmt.Printf("✂️  %d/%d %s\n", i+1, len(groups), group.Title)
+		result := generateCommitMessage(SplitCmd, config, patches[i], "split")
+		messages[i] = withSignature(config, result)
+	}
+
+	option := ui.CommitOptionProceed
+	switch {
+	case Approve:
+	case Edit:
+		option = ui.CommitOptionEdit
+	default:
+		fmt.Println("💭 Your therapist's recommendations:")
+		for i, message := range messages {
+			fmt.Printf("\n%d/%d\n", i+1, len(messages))
+			fmt.Println("```text")
+			color.New(color.FgGreen, color.Bold).Println(message)
+			fmt.Println("```")
+			printViolations(lint.Lint(lint.CleanMessage(message, lint.DefaultCommentChar), rules))
+		}
+		fmt.Println()
+
+		var err error
+		option, err = ui.SelectCommit()
+		handleQuitError(err)
+		if err != nil {
+			log.Printf("Error confirming commits: %v", err)
+			exit(1)
+		}
+	}
+
+	switch option {
+	case ui.CommitOptionEdit:
+		fmt.Println("📝 Opening your personal therapy journal (editor) for each commit...")
+		for i, message := range messages {
+			edited, err := utils.EditMessage(message)
+			if err != nil {
+				if Verbose {
+					log.Printf("Error editing message: %v", err)
+				}
+				exitSplit("Your self-therapy session was interrupted! Nothing was committed.")
+			}
+			messages[i] = lint.CleanMessage(edited, lint.DefaultCommentChar)
+			if messages[i] == "" {
+				exitSplit("Commit %d was left without a message. Nothing was committed.", i+1)
+			}
+		}
+	case ui.CommitOptionRerun:
+		return proposeSplitMessages(config, groups, patches)
+	case ui.CommitOptionExit:
+		fmt.Println("🧐 You're on your own path now. Call if your commitment issues return!")
+		exit(0)
+	}
+
+	return messages
+}
+
+// applySplit commits each patch in turn, starting from an index that matches
+// HEAD. On any failure HEAD and the index are put back as they were.
+func applySplit(patches, messages []string) {
+	head, err := utils.ExecGit("rev-parse", "--verify", "HEAD")
+	if err != nil {
+		exitSplit("Couldn't find your current commit.")
+	}
+	head = strings.TrimSpace(head)
+
+	stagedTree, err := utils.ExecGit("write-tree")
+	if err != nil {
+		exitSplit("Your index has unresolved conflicts. Work through those first.")
+	}
+	stagedTree = strings.TrimSpace(stagedTree)
+
+	backup, err := utils.BackupIndex()
+	if err != nil {
+		if Verbose {
+			log.Printf("Error backing up the index: %v", err)
+		}
+		exitSplit("Couldn't keep a backup of your index, so it stays untouched.")
+	}
+
+	backupRef, err := utils.CreateBackupRef("split", head)
+	if err != nil {
+		exitSplit("Couldn't keep a backup of your history, so it stays untouched.")
+	}
+
+	restore := func(format string, args ...any) {
+		if current, err := utils.ExecGit("rev-parse", "--verify", "HEAD"); err == nil && strings.TrimSpace(current) != head {
+			if err := utils.UpdateHead(strings.TrimSpace(current), head, "split aborted"); err != nil {
+				log.Printf("Error restoring HEAD: %v", err)
+			}
+		}
+		if err := backup.Restore(); err != nil {
+			log.Printf("Error restoring the index: %v", err)
+			fmt.Printf("(Your original HEAD is kept at %s.)\n", backupRef)
+		}
+		exitSplit(format+" Everything was put back as it was.", args...)
+	}
+
+	if _, err := utils.ExecGit("read-tree", head); err != nil {
+		if Verbose {
+			log.Printf("Error resetting the index: %v", err)
+		}
+		restore("Couldn't prepare the index.")
+	}
+
+	fmt.Println("🧐 Preparing for your code's commitment ceremonies...")
+	for i, p := range patches {
+		if _, err := utils.ExecGitWithInput(p, nil, "apply", "--cached", "-"); err != nil {
+			if Verbose {
+				log.Printf("Error applying patch %d: %v\n%s", i+1, err, p)
+			}
+			restore("Commit %d/%d didn't apply cleanly.", i+1, len(patches))
+		}
+
+		tempFilePath := writeTempMessage(SplitCmd, messages[i])
+		err := runGitCommit(append(gitCommitArgs(), "-q", "-F", tempFilePath)...)
+		os.Remove(tempFilePath)
+		if err != nil {
+			if Verbose {
+				log.Printf("Error committing: %v", err)
+			}
+			restore("Refusing to make commit %d/%d.", i+1, len(patches))
+		}
+	}
+
+	// A hook that rewrote the staged content would silently change the result
+	tree, err := utils.ExecGit("rev-parse", "HEAD^{tree}")
+	if err != nil || strings.TrimSpace(tree) != stagedTree {
+		restore("The commits don't add up to what you had staged.")
+	}
+
+	if _, err := utils.ExecGit("update-index", "-q", "--refresh"); err != nil && Verbose {
+		log.Printf("Error refreshing the index: %v", err)
+	}
+
+	fmt.Printf("🎓 Split into %d commits! Each of them knows exactly what it stands for.\n", len(patches))
+	fmt.Printf("(Changed your mind? `git reset --soft %s` undoes it.)\n", backupRef)
+}
+
+var SplitBy string
+
+func init() {
+	splitCmd.Flags().StringVar(&SplitBy, "by", splitByIntent, usageSplitBy)
+	addGitCommitFlags(splitCmd)
+
+	rootCmd.AddCommand(splitCmd)
+}
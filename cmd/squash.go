@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/llm"
+	"github.com/cowboy-bebug/kommit/internal/ui"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// The net diff is trimmed to this many lines for the prompt
+	maxSquashDiffLines = 2000
+
+	usageSquashForce = "Squash even if the branch has already been pushed"
+	usageSquashPrint = "Print the message instead of squashing, e.g. for a squash merge on GitHub"
+)
+
+var squashCmd = &cobra.Command{
+	Use:   "squash [base]",
+	Short: "🫂 Squash your branch into one commit with a message that sums it up",
+	Long: `🫂 Kommit Squash - One story for a branch full of "wip"!
+
+This command reads every commit on your branch since it forked from base (the
+default branch of your upstream or origin remote, unless you say otherwise),
+along with their net diff, and writes a single conventional message for the
+whole branch. Everyone who worked on it is kept as a Co-authored-by trailer.
+
+Accept the message and kommit squashes the branch for you, keeping a backup
+ref so it can be undone. Branches that have already been pushed are left alone
+unless you pass --force. With --print, the message is only printed, ready for
+a squash merge on your forge:
+
+  git kommit squash --print | pbcopy`,
+	Run: runSquash,
+}
+
+// squashArgs accepts the base before "--" and keeps anything after it for
+// `git commit`.
+func squashArgs(cmd *cobra.Command, args []string) error {
+	positional := args
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		positional, CommitExtra = args[:dash], args[dash:]
+	}
+	return cobra.MaximumNArgs(1)(cmd, positional)
+}
+
+func exitSquash(format string, args ...any) {
+	exitScript(SquashCmd, 1, format, args...)
+}
+
+func runSquash(cmd *cobra.Command, args []string) {
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args = args[:dash]
+	}
+
+	if utils.IsRebaseInProgress() {
+		exitSquash("Finish your rebase before bringing the group together.")
+	}
+
+	head, err := utils.ExecGit("rev-parse", "--verify", "HEAD")
+	if err != nil {
+		exitSquash("There are no commits to squash yet.")
+	}
+	head = strings.TrimSpace(head)
+
+	base := ""
+	if len(args) > 0 {
+		base = args[0]
+	} else if base, err = utils.GetDefaultBase(); err != nil {
+		exitSquash("Couldn't tell which branch you forked from. Pass it along: `git kommit squash main`.")
+	}
+
+	mergeBase, err := utils.GetMergeBase(base)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error finding merge base: %v", err)
+		}
+		exitSquash("Your branch and %s have nothing in common.", base)
+	}
+	revisionRange := mergeBase + "..HEAD"
+
+	commits, err := utils.GetCommitMessages(revisionRange)
+	if err != nil || len(commits) == 0 {
+		if Verbose && err != nil {
+			log.Printf("Error reading commits: %v", err)
+		}
+		exitScript(SquashCmd, exitNothingStaged, "Your branch has no commits of its own since %s.", base)
+	}
+
+	if !Print {
+		checkSquashable(head)
+	}
+
+	diff, err := utils.ExecGit("diff", mergeBase, "HEAD")
+	if err != nil || diff == "" {
+		if Verbose && err != nil {
+			log.Printf("Error getting diff: %v", err)
+		}
+		exitScript(SquashCmd, exitNothingStaged, "Your branch's commits cancel each other out, so there's nothing to sum up.")
+	}
+
+	var config *utils.Config
+	if Print {
+		config = loadScriptConfig(SquashCmd)
+		enforceScriptBudget(SquashCmd, config.Budget)
+	} else {
+		config = loadConfig(SquashCmd)
+		enforceBudget(SquashCmd, config.Budget)
+	}
+
+	messages := make([]string, len(commits))
+	for i, commit := range commits {
+		messages[i] = commit.Message
+	}
+	result := generateSquashMessage(config, messages, trimLines(diff, maxSquashDiffLines))
+	if !Print {
+		result.Message = reviewBreakingChange(diff, result.Message)
+	}
+
+	commitMessage := withCoAuthors(revisionRange, withSignature(config, result))
+
+	if Print {
+		fmt.Println(commitMessage)
+		return
+	}
+
+	violations := lint.Lint(result.Message, configRules(config))
+	option := reviewCommitMessage(commitMessage, violations)
+	switch option {
+	case ui.CommitOptionProceed, ui.CommitOptionEdit:
+		squashBranch(option, head, mergeBase, commitMessage, len(commits))
+	case ui.CommitOptionRerun:
+		runSquash(cmd, args)
+	case ui.CommitOptionExit:
+		fmt.Printf("🧐 Your branch keeps its %d commit(s). Call if your commitment issues return!\n", len(commits))
+		exit(0)
+	}
+}
+
+// checkSquashable refuses to squash over staged changes, which would sneak
+// into the squashed commit, or a branch that has already been pushed, unless
+// forced.
+func checkSquashable(head string) {
+	if _, err := utils.ExecGit("diff", "--cached", "--quiet"); err != nil {
+		exitSquash("You have staged changes. Commit or unstage them before squashing.")
+	}
+
+	remotes, err := utils.GetRemoteBranchesContaining(head)
+	if err != nil && Verbose {
+		log.Printf("Error checking remote branches: %v", err)
+	}
+	if len(remotes) > 0 {
+		if !SquashForce {
+			exitSquash("Your branch has already been shared with %s. (Squashing it means a force push. Pass --force if you really mean it.)", strings.Join(remotes, ", "))
+		}
+		fmt.Printf("⚠️  Your branch has already been shared with %s. You'll need to force push.\n", strings.Join(remotes, ", "))
+	}
+}
+
+// generateSquashMessage asks the therapist for one message for the whole
+// branch, exiting on failure.
+func generateSquashMessage(config *utils.Config, commits []string, diff string) llm.ChatResult[string] {
+	s := ui.Spinner("🧐 Helping your commits agree on one story...")
+	if Print {
+		s.Disable()
+	}
+	s.Start()
+	result, err := llm.GenerateSquashMessage(config, commits, diff, Message)
+	s.Stop()
+	if err != nil {
+		exitProviderFailure(SquashCmd, err)
+	}
+	recordCost("squash", result)
+
+	return repairCommitMessage(config, diff, "squash", result)
+}
+
+// withCoAuthors credits everyone who authored or co-authored the squashed
+// commits, other than the author of the squashed commit itself.
+func withCoAuthors(revisionRange, message string) string {
+	author := utils.GetAuthorEmail()
+	if CommitAuthor != "" {
+		if _, email, ok := strings.Cut(CommitAuthor, "<"); ok {
+			author = strings.TrimSuffix(strings.TrimSpace(email), ">")
+		}
+	}
+
+	coAuthors, err := utils.GetCoAuthors(revisionRange, author)
+	if err != nil || len(coAuthors) == 0 {
+		if Verbose && err != nil {
+			log.Printf("Error reading co-authors: %v", err)
+		}
+		return message
+	}
+
+	trailers := make([]string, len(coAuthors))
+	for i, coAuthor := range coAuthors {
+		trailers[i] = "Co-authored-by: " + coAuthor
+	}
+	credited, err := utils.AddTrailers(message, trailers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Couldn't credit your co-authors: %v\n", err)
+		return message
+	}
+	return credited
+}
+
+// squashBranch moves the branch back to the merge base, keeping the index and
+// working tree, and commits everything at once. If the commit fails, the
+// branch is put back where it was.
+func squashBranch(option ui.CommitOption, head, mergeBase, commitMessage string, count int) {
+	backupRef, err := utils.CreateBackupRef("squash", head)
+	if err != nil {
+		if Verbose {
+			log.Printf("Error creating backup ref: %v", err)
+		}
+		exitSquash("Couldn't keep a backup of your branch, so it stays untouched.")
+	}
+
+	tempFilePath := writeTempMessage(SquashCmd, commitMessage)
+	defer os.Remove(tempFilePath) // Clean up the temp file when done
+
+	if err := utils.UpdateHead(head, mergeBase, "squash onto "+mergeBase); err != nil {
+		os.Remove(tempFilePath)
+		if Verbose {
+			log.Printf("Error resetting branch: %v", err)
+		}
+		exitSquash("Couldn't gather your commits. Nothing was changed.")
+	}
+
+	args := gitCommitArgs()
+	if option == ui.CommitOptionEdit {
+		fmt.Println("📝 Opening your personal therapy journal (editor)...")
+		args = append(args, "-e", "-F", tempFilePath)
+	} else {
+		fmt.Println("🧐 Preparing for your branch's commitment ceremony...")
+		args = append(args, "-q", "-F", tempFilePath)
+	}
+
+	if err := runGitCommit(args...); err != nil {
+		os.Remove(tempFilePath)
+		if Verbose {
+			log.Printf("Error committing: %v", err)
+		}
+		if err := utils.UpdateHead(mergeBase, head, "undo squash"); err != nil {
+			if Verbose {
+				log.Printf("Error restoring branch: %v", err)
+			}
+			exitSquash("Refusing to squash, and couldn't put your branch back. `git reset --soft %s` restores it.", backupRef)
+		}
+		exitSquash("Refusing to squash! Your branch is back as it was.")
+	}
+
+	fmt.Printf("🎓 Squashed %d commit(s) into one! Your branch finally tells a single story.\n", count)
+	fmt.Printf("(Changed your mind? `git reset --soft %s` undoes it.)\n", backupRef)
+}
+
+var SquashForce bool
+
+func init() {
+	squashCmd.Flags().BoolVarP(&SquashForce, "force", "f", false, usageSquashForce)
+	squashCmd.Flags().BoolVar(&Print, "print", false, usageSquashPrint)
+	addGitCommitFlags(squashCmd)
+	squashCmd.Args = squashArgs
+
+	rootCmd.AddCommand(squashCmd)
+}
@@ -0,0 +1,261 @@
+// Package breaking spots changes in a diff that are likely to break users:
+// removed exported Go identifiers, changed function signatures, deleted
+// routes and flags, and removed config keys. The heuristics only look at the
+// lines a diff removes and adds, so they are hints for the model and the
+// user rather than a verdict.
+package breaking
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/patch"
+)
+
+type Kind string
+
+const (
+	KindRemovedIdentifier Kind = "removed exported identifier"
+	KindChangedSignature  Kind = "changed function signature"
+	KindRemovedRoute      Kind = "removed route"
+	KindRemovedFlag       Kind = "removed flag"
+	KindRemovedConfigKey  Kind = "removed config key"
+)
+
+type Finding struct {
+	File string
+	Kind Kind
+	Name string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s `%s`", f.File, f.Kind, f.Name)
+}
+
+// goBlock is the Go declaration a line sits in, which tells a struct field
+// or a const member apart from a statement in a function body.
+type goBlock int
+
+const (
+	goBlockNone goBlock = iota
+	goBlockStruct
+	// const, var and type groups
+	goBlockGroup
+)
+
+// declaration finds something in a single diff line, returning its name and
+// the normalised declaration to compare removed and added versions by.
+type declaration struct {
+	kind  Kind
+	match func(file, line string, block goBlock) (name, signature string, ok bool)
+}
+
+var (
+	goFuncRegex   = regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?([A-Z]\w*)\s*(?:\[[^\]]*\])?\((.*)$`)
+	goDeclRegex   = regexp.MustCompile(`^(?:type|var|const)\s+([A-Z]\w*)\b`)
+	goFieldRegex  = regexp.MustCompile(`^\s+([A-Z]\w*)(?:\s*$|\s*=|\s+[^\s=:])`)
+	goStructRegex = regexp.MustCompile(`^type\s+\w+(?:\[[^\]]*\])?\s+struct\s*\{\s*(?://.*)?$`)
+	goGroupRegex  = regexp.MustCompile(`^(?:const|var|type)\s*\(`)
+	yamlKeyRegex  = regexp.MustCompile(`^\s*(?:-\s+)?([A-Za-z_][\w.-]*)\s*:(?:\s|$)`)
+	tomlKeyRegex  = regexp.MustCompile(`^\s*(?:([A-Za-z_][\w.-]*)\s*=|\[\[?\s*([A-Za-z_][\w.-]*)\s*\]\]?\s*$)`)
+	jsonKeyRegex  = regexp.MustCompile(`^\s*"([^"]+)"\s*:`)
+	routeRegex    = regexp.MustCompile(`(?i)(?:\.|@\w+\.)(?:get|post|put|patch|delete|head|options|handle|handlefunc|route|any)\(\s*["'` + "`" + `](/[^"'` + "`" + `]*)["'` + "`" + `]`)
+	flagRegex     = regexp.MustCompile(`(?:Flags\(\)\.\w+|flag\.\w+|add_argument|\.option)\(\s*(?:&\w+,\s*)?["'` + "`" + `](?:--)?([a-zA-Z][\w-]*)["'` + "`" + `]`)
+	tagRegex      = regexp.MustCompile("(?:mapstructure|yaml|toml|json|env):\"([^\",]+)")
+)
+
+var declarations = []declaration{
+	{
+		kind: KindChangedSignature,
+		match: func(file, line string, block goBlock) (string, string, bool) {
+			if !isGoSource(file) {
+				return "", "", false
+			}
+			matches := goFuncRegex.FindStringSubmatch(line)
+			if matches == nil {
+				return "", "", false
+			}
+			return matches[1], strings.Join(strings.Fields(strings.TrimSuffix(matches[2], "{")), " "), true
+		},
+	},
+	{
+		kind: KindRemovedIdentifier,
+		match: func(file, line string, block goBlock) (string, string, bool) {
+			if !isGoSource(file) {
+				return "", "", false
+			}
+			if matches := goDeclRegex.FindStringSubmatch(line); matches != nil {
+				return matches[1], "", true
+			}
+			// Struct fields and members of const, var and type groups
+			if block == goBlockNone {
+				return "", "", false
+			}
+			if matches := goFieldRegex.FindStringSubmatch(line); matches != nil {
+				return matches[1], "", true
+			}
+			return "", "", false
+		},
+	},
+	{
+		kind: KindRemovedRoute,
+		match: func(file, line string, block goBlock) (string, string, bool) {
+			if matches := routeRegex.FindStringSubmatch(line); matches != nil {
+				return matches[1], "", true
+			}
+			return "", "", false
+		},
+	},
+	{
+		kind: KindRemovedFlag,
+		match: func(file, line string, block goBlock) (string, string, bool) {
+			if matches := flagRegex.FindStringSubmatch(line); matches != nil {
+				return matches[1], "", true
+			}
+			return "", "", false
+		},
+	},
+	{
+		kind: KindRemovedConfigKey,
+		match: func(file, line string, block goBlock) (string, string, bool) {
+			if matches := tagRegex.FindStringSubmatch(line); matches != nil && matches[1] != "-" {
+				return matches[1], "", true
+			}
+			if name := configKey(file, line); name != "" {
+				return name, "", true
+			}
+			return "", "", false
+		},
+	},
+}
+
+// configKey returns the key a line of a YAML, TOML or JSON config file
+// defines. CI workflows and lock files aren't anyone's config.
+func configKey(file, line string) string {
+	base := path.Base(file)
+	if strings.HasPrefix(file, ".github/") || strings.HasPrefix(file, ".circleci/") ||
+		strings.HasPrefix(base, ".gitlab-ci") || strings.Contains(base, "lock") {
+		return ""
+	}
+
+	var matches []string
+	switch path.Ext(file) {
+	case ".yaml", ".yml":
+		matches = yamlKeyRegex.FindStringSubmatch(line)
+	case ".toml":
+		matches = tomlKeyRegex.FindStringSubmatch(line)
+	case ".json":
+		matches = jsonKeyRegex.FindStringSubmatch(line)
+	}
+	for _, match := range matches[min(len(matches), 1):] {
+		if match != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+// nextGoBlock follows a Go source line into or out of a declaration.
+func nextGoBlock(block goBlock, line string) goBlock {
+	switch {
+	case goStructRegex.MatchString(line):
+		return goBlockStruct
+	case goGroupRegex.MatchString(line):
+		return goBlockGroup
+	case strings.HasPrefix(line, "}"), strings.HasPrefix(line, ")"), strings.HasPrefix(line, "func "):
+		return goBlockNone
+	}
+	return block
+}
+
+// sectionGoBlock is the block a hunk starts in, judging by the declaration
+// git names in its header.
+func sectionGoBlock(section string) goBlock {
+	return nextGoBlock(goBlockNone, strings.TrimSpace(section))
+}
+
+func isGoSource(file string) bool {
+	return path.Ext(file) == ".go" && !strings.HasSuffix(file, "_test.go")
+}
+
+// Detect looks for likely breaking changes in a diff. Something counts as
+// removed when the diff deletes it and doesn't add it back anywhere, since
+// moved code is not a breaking change.
+func Detect(diff string) []Finding {
+	files, err := patch.Parse(diff)
+	if err != nil {
+		return nil
+	}
+
+	type key struct {
+		kind Kind
+		name string
+	}
+	type removal struct {
+		finding   Finding
+		signature string
+	}
+	removed := make(map[key]removal)
+	added := make(map[key][]string)
+	var order []key
+
+	for _, f := range files {
+		for _, h := range f.Hunks {
+			// The old and new sides of a hunk can sit in different blocks
+			oldBlock, newBlock := sectionGoBlock(h.Section), sectionGoBlock(h.Section)
+			for _, line := range h.Lines {
+				if line == "" || line[0] == '\\' {
+					continue
+				}
+				block := oldBlock
+				if line[0] == '+' {
+					block = newBlock
+				}
+				if line[0] != '+' {
+					oldBlock = nextGoBlock(oldBlock, line[1:])
+				}
+				if line[0] != '-' {
+					newBlock = nextGoBlock(newBlock, line[1:])
+				}
+				if line[0] == ' ' {
+					continue
+				}
+
+				for _, d := range declarations {
+					name, signature, ok := d.match(f.Path(), line[1:], block)
+					if !ok {
+						continue
+					}
+					k := key{d.kind, name}
+					if line[0] == '+' {
+						added[k] = append(added[k], signature)
+					} else if _, seen := removed[k]; !seen {
+						removed[k] = removal{
+							finding:   Finding{File: f.OldPath, Kind: d.kind, Name: name},
+							signature: signature,
+						}
+						order = append(order, k)
+					}
+					break
+				}
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, k := range order {
+		r := removed[k]
+		signatures, readded := added[k]
+		switch {
+		case readded && (k.kind != KindChangedSignature || slices.Contains(signatures, r.signature)):
+			continue
+		case !readded && k.kind == KindChangedSignature:
+			r.finding.Kind = KindRemovedIdentifier
+		}
+		findings = append(findings, r.finding)
+	}
+	return findings
+}
@@ -0,0 +1,213 @@
+package breaking
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// diffOf builds a one-hunk diff of file from removed and added lines, after
+// some context lines. section is the declaration git names in the header.
+func diffOf(file, section string, context, removed, added []string) string {
+	var b strings.Builder
+	b.WriteString("diff --git a/" + file + " b/" + file + "\n")
+	b.WriteString("--- a/" + file + "\n+++ b/" + file + "\n")
+	fmt.Fprintf(&b, "@@ -1,%d +1,%d @@ %s\n", len(context)+len(removed), len(context)+len(added), section)
+	for _, line := range context {
+		b.WriteString(" " + line + "\n")
+	}
+	for _, line := range removed {
+		b.WriteString("-" + line + "\n")
+	}
+	for _, line := range added {
+		b.WriteString("+" + line + "\n")
+	}
+	return b.String()
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		section string
+		context []string
+		removed []string
+		added   []string
+		want    []string
+	}{
+		{
+			name:    "removed function",
+			file:    "api.go",
+			removed: []string{"func Refund(id string) error {"},
+			want:    []string{"api.go: removed exported identifier `Refund`"},
+		},
+		{
+			name:    "changed signature",
+			file:    "api.go",
+			removed: []string{"func (c *Client) Refund(id string) error {"},
+			added:   []string{"func (c *Client) Refund(ctx context.Context, id string) error {"},
+			want:    []string{"api.go: changed function signature `Refund`"},
+		},
+		{
+			name:    "reformatted signature",
+			file:    "api.go",
+			removed: []string{"func Refund(id string)  error {"},
+			added:   []string{"func Refund(id string) error {"},
+		},
+		{
+			name:    "moved type",
+			file:    "api.go",
+			removed: []string{"type Client struct {"},
+			added:   []string{"type Client struct {"},
+		},
+		{
+			name:    "unexported function",
+			file:    "api.go",
+			removed: []string{"func refund() {"},
+		},
+		{
+			name:    "tests",
+			file:    "api_test.go",
+			removed: []string{"func TestRefund(t *testing.T) {"},
+		},
+		{
+			name:    "struct field with a tag",
+			file:    "config.go",
+			context: []string{"type Config struct {"},
+			removed: []string{"\tTimeout time.Duration `yaml:\"timeout\"`"},
+			want:    []string{"config.go: removed exported identifier `Timeout`"},
+		},
+		{
+			name:    "struct field with a function type",
+			file:    "config.go",
+			section: "type Config struct {",
+			removed: []string{"\tOnDone func(err error)"},
+			want:    []string{"config.go: removed exported identifier `OnDone`"},
+		},
+		{
+			name:    "composite literal key",
+			file:    "main.go",
+			section: "type Config struct {",
+			removed: []string{"\tTimeout: 5 * time.Second,"},
+		},
+		{
+			name:    "short variable declaration",
+			file:    "main.go",
+			removed: []string{"\tErr := run()"},
+		},
+		{
+			name:    "const block member",
+			file:    "kind.go",
+			context: []string{"const (", "\tKindCharge Kind = \"charge\""},
+			removed: []string{"\tKindRefund Kind = \"refund\""},
+			want:    []string{"kind.go: removed exported identifier `KindRefund`"},
+		},
+		{
+			name:    "assignment in a function body",
+			file:    "main.go",
+			section: "func run() error {",
+			removed: []string{"\tTimeout = 5 * time.Second"},
+		},
+		{
+			name:    "statement after a struct closes",
+			file:    "main.go",
+			context: []string{"type Config struct {", "\tTimeout int", "}", "", "func run() {"},
+			removed: []string{"\tLog x"},
+		},
+		{
+			name:    "capitalised statement without context",
+			file:    "main.go",
+			removed: []string{"\tFoo = bar"},
+		},
+		{
+			name:    "field turned into a method",
+			file:    "config.go",
+			context: []string{"type Config struct {"},
+			removed: []string{"\tTimeout int"},
+			added:   []string{"}", "", "func (c Config) Timeout() int {"},
+			want:    []string{"config.go: removed exported identifier `Timeout`"},
+		},
+		{
+			name:    "route",
+			file:    "server.ts",
+			removed: []string{"app.get('/v1/users', listUsers)"},
+			added:   []string{"app.get('/v2/users', listUsers)"},
+			want:    []string{"server.ts: removed route `/v1/users`"},
+		},
+		{
+			name:    "flag",
+			file:    "cli.py",
+			removed: []string{"parser.add_argument('--dry-run', action='store_true')"},
+			want:    []string{"cli.py: removed flag `dry-run`"},
+		},
+		{
+			name:    "config key",
+			file:    "settings.py",
+			removed: []string{`    timeout: int = Field(env:"TIMEOUT")`},
+			want:    []string{"settings.py: removed config key `TIMEOUT`"},
+		},
+		{
+			name:    "yaml key",
+			file:    "config/default.yaml",
+			context: []string{"server:"},
+			removed: []string{"  timeout: 5s", "  # retries: 3"},
+			added:   []string{"  deadline: 5s"},
+			want:    []string{"config/default.yaml: removed config key `timeout`"},
+		},
+		{
+			name:    "yaml list item key",
+			file:    "values.yml",
+			removed: []string{"  - name: api"},
+			want:    []string{"values.yml: removed config key `name`"},
+		},
+		{
+			name:    "yaml value changed",
+			file:    "values.yml",
+			removed: []string{"replicas: 2"},
+			added:   []string{"replicas: 3"},
+		},
+		{
+			name:    "toml key and table",
+			file:    "pyproject.toml",
+			removed: []string{"[tool.cache]", "max_size = 100"},
+			want: []string{
+				"pyproject.toml: removed config key `tool.cache`",
+				"pyproject.toml: removed config key `max_size`",
+			},
+		},
+		{
+			name:    "json key",
+			file:    "settings.json",
+			removed: []string{`  "telemetry.enabled": true,`},
+			want:    []string{"settings.json: removed config key `telemetry.enabled`"},
+		},
+		{
+			name:    "json key moved",
+			file:    "settings.json",
+			removed: []string{`  "theme": "dark",`},
+			added:   []string{`    "theme": "dark"`},
+		},
+		{
+			name:    "ci workflow",
+			file:    ".github/workflows/ci.yml",
+			removed: []string{"  timeout-minutes: 10"},
+		},
+		{
+			name:    "lock file",
+			file:    "package-lock.json",
+			removed: []string{`  "lockfileVersion": 2,`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, finding := range Detect(diffOf(tt.file, tt.section, tt.context, tt.removed, tt.added)) {
+				got = append(got, finding.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Package changelog turns conventional commits into Keep a Changelog style
+// release sections, and merges them into an existing CHANGELOG.md.
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+)
+
+const (
+	DefaultFile          = "CHANGELOG.md"
+	DefaultBreakingTitle = "⚠️ Breaking Changes"
+	Unreleased           = "Unreleased"
+
+	// Shortened hashes in links
+	shortHashLength = 7
+
+	preamble = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+)
+
+// DefaultSections follow Keep a Changelog's categories. Types such as docs,
+// chore or test rarely matter to users, so they are left out.
+var DefaultSections = []utils.ChangelogSection{
+	{Type: "feat", Title: "Added"},
+	{Type: "fix", Title: "Fixed"},
+	{Type: "perf", Title: "Changed"},
+	{Type: "revert", Title: "Changed"},
+}
+
+// Footer tokens whose values are ticket or issue references
+var referenceTokens = []string{
+	"refs", "ref", "references", "see", "related",
+	"closes", "close", "closed", "fixes", "fix", "fixed", "resolves", "resolve", "resolved",
+}
+
+var (
+	scpRemoteRegex = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):(.+?)(?:\.git)?/?$`)
+	urlRemoteRegex = regexp.MustCompile(`^(?:https?|ssh|git)://(?:[^@/]+@)?([^/:]+)(?::\d+)?/(.+?)(?:\.git)?/?$`)
+	issueRegex     = regexp.MustCompile(`^#(\d+)$`)
+)
+
+type Entry struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	// BreakingChange is the BREAKING CHANGE footer, if any
+	BreakingChange string
+	References     []string
+}
+
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+type Release struct {
+	Version string
+	// Date is empty for unreleased changes
+	Date string
+	// CompareURL links the version to its diff, if the repo's URL is known
+	CompareURL string
+	Breaking   []Entry
+	Sections   []Section
+}
+
+// Entries parses the commits as conventional commits, skipping those that
+// aren't and those git wrote itself. referenceToken is an extra footer token
+// to read references from.
+func Entries(commits []utils.CommitMessage, referenceToken string) ([]Entry, int) {
+	tokens := referenceTokens
+	if referenceToken != "" {
+		tokens = append(slices.Clone(tokens), strings.ToLower(referenceToken))
+	}
+
+	var entries []Entry
+	skipped := 0
+	for _, commit := range commits {
+		message := lint.CleanMessage(commit.Message, lint.DefaultCommentChar)
+		if lint.IsGeneratedMessage(message) {
+			skipped++
+			continue
+		}
+		m, err := conventional.Parse(message)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		entry := Entry{
+			Hash:           commit.Hash,
+			Type:           m.Type,
+			Scope:          m.Scope,
+			Description:    m.Description,
+			Breaking:       m.IsBreaking(),
+			BreakingChange: m.BreakingChange(),
+		}
+		for _, footer := range m.Footers {
+			if !slices.Contains(tokens, strings.ToLower(footer.Token)) {
+				continue
+			}
+			// "Closes #7" uses the issue's hash as its separator
+			value := footer.Value
+			if strings.HasSuffix(footer.Separator, "#") {
+				value = "#" + value
+			}
+			for _, reference := range strings.Split(value, ",") {
+				if reference = strings.TrimSpace(reference); reference != "" && !slices.Contains(entry.References, reference) {
+					entry.References = append(entry.References, reference)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped
+}
+
+// Build sorts the entries into the configured sections. Breaking changes get
+// a section of their own, whatever their type.
+func Build(version, date string, entries []Entry, config utils.ChangelogConfig) Release {
+	sections := config.Sections
+	if len(sections) == 0 {
+		sections = DefaultSections
+	}
+
+	release := Release{Version: version, Date: date}
+	titles := make(map[string]string)
+	for _, section := range sections {
+		titles[section.Type] = section.Title
+		if !slices.ContainsFunc(release.Sections, func(s Section) bool { return s.Title == section.Title }) {
+			release.Sections = append(release.Sections, Section{Title: section.Title})
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Breaking {
+			release.Breaking = append(release.Breaking, entry)
+			continue
+		}
+		title, ok := titles[entry.Type]
+		if !ok {
+			continue
+		}
+		i := slices.IndexFunc(release.Sections, func(s Section) bool { return s.Title == title })
+		release.Sections[i].Entries = append(release.Sections[i].Entries, entry)
+	}
+
+	// Group each section by scope, keeping unscoped entries first
+	for i := range release.Sections {
+		slices.SortStableFunc(release.Sections[i].Entries, func(a, b Entry) int {
+			return strings.Compare(a.Scope, b.Scope)
+		})
+	}
+	release.Sections = slices.DeleteFunc(release.Sections, func(s Section) bool { return len(s.Entries) == 0 })
+
+	return release
+}
+
+// Markdown renders the release as a "## [version] - date" section. Hashes and
+// GitHub-style issue references link to repoURL when it's set.
+func (r Release) Markdown(repoURL, breakingTitle string) string {
+	if breakingTitle == "" {
+		breakingTitle = DefaultBreakingTitle
+	}
+
+	var b strings.Builder
+	heading := "[" + r.Version + "]"
+	if r.CompareURL != "" {
+		heading += "(" + r.CompareURL + ")"
+	}
+	if r.Date != "" {
+		heading += " - " + r.Date
+	}
+	b.WriteString("## " + heading + "\n")
+
+	if len(r.Breaking) > 0 {
+		b.WriteString("\n### " + breakingTitle + "\n\n")
+		for _, entry := range r.Breaking {
+			b.WriteString(renderEntry(entry, repoURL))
+			if entry.BreakingChange != "" {
+				b.WriteString("  " + strings.ReplaceAll(entry.BreakingChange, "\n", "\n  ") + "\n")
+			}
+		}
+	}
+
+	for _, section := range r.Sections {
+		b.WriteString("\n### " + section.Title + "\n\n")
+		for _, entry := range section.Entries {
+			b.WriteString(renderEntry(entry, repoURL))
+		}
+	}
+
+	if len(r.Breaking) == 0 && len(r.Sections) == 0 {
+		b.WriteString("\nNo notable changes.\n")
+	}
+
+	return b.String()
+}
+
+// Text renders the release as plain text without links, e.g. for the message
+// of an annotated tag, where markdown headings would read as comments.
+func (r Release) Text(breakingTitle string) string {
+	if breakingTitle == "" {
+		breakingTitle = DefaultBreakingTitle
+	}
+
+	var b strings.Builder
+	b.WriteString(r.Version + "\n")
+	write := func(title string, entries []Entry) {
+		b.WriteString("\n" + title + ":\n")
+		for _, entry := range entries {
+			line := "- "
+			if entry.Scope != "" {
+				line += entry.Scope + ": "
+			}
+			line += entry.Description
+			if len(entry.References) > 0 {
+				line += " (" + strings.Join(entry.References, ", ") + ")"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if len(r.Breaking) > 0 {
+		write(breakingTitle, r.Breaking)
+	}
+	for _, section := range r.Sections {
+		write(section.Title, section.Entries)
+	}
+	return b.String()
+}
+
+func renderEntry(entry Entry, repoURL string) string {
+	line := "- "
+	if entry.Scope != "" {
+		line += "**" + entry.Scope + ":** "
+	}
+	line += entry.Description
+
+	hash := entry.Hash[:min(len(entry.Hash), shortHashLength)]
+	if repoURL != "" {
+		hash = fmt.Sprintf("[%s](%s/commit/%s)", hash, repoURL, entry.Hash)
+	}
+	links := []string{hash}
+	for _, reference := range entry.References {
+		if matches := issueRegex.FindStringSubmatch(reference); matches != nil && repoURL != "" {
+			reference = fmt.Sprintf("[%s](%s/issues/%s)", reference, repoURL, matches[1])
+		}
+		links = append(links, reference)
+	}
+
+	return line + " (" + strings.Join(links, ", ") + ")\n"
+}
+
+// CompareURL links the changes between two refs, or everything up to "to"
+// when there is no "from".
+func CompareURL(repoURL, from, to string) string {
+	if repoURL == "" {
+		return ""
+	}
+	if from == "" {
+		return repoURL + "/commits/" + to
+	}
+	return repoURL + "/compare/" + from + "..." + to
+}
+
+// WebURL turns a remote URL such as git@github.com:org/repo.git into the
+// repo's web address, or "" for remotes that aren't on a web host.
+func WebURL(remote string) string {
+	remote = strings.TrimSpace(remote)
+	for _, regex := range []*regexp.Regexp{urlRemoteRegex, scpRemoteRegex} {
+		if matches := regex.FindStringSubmatch(remote); matches != nil {
+			if strings.HasPrefix(remote, "/") || strings.HasPrefix(remote, "file:") {
+				return ""
+			}
+			return "https://" + matches[1] + "/" + matches[2]
+		}
+	}
+	if strings.HasPrefix(remote, "https://") {
+		return strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	}
+	return ""
+}
+
+// Update puts the release section into an existing changelog, replacing the
+// section for the same version (or the unreleased one) and otherwise adding
+// it above the latest release. An empty changelog gets the usual preamble.
+func Update(existing, section, version string) string {
+	if strings.TrimSpace(existing) == "" {
+		return preamble + "\n" + section
+	}
+
+	lines := strings.Split(existing, "\n")
+	start, end := -1, len(lines)
+	first := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		if first == -1 {
+			first = i
+		}
+		if start != -1 {
+			end = i
+			break
+		}
+		name := sectionVersion(line)
+		if name == version || (name == Unreleased && version != Unreleased) {
+			start = i
+		}
+	}
+
+	var before, after []string
+	switch {
+	case start != -1:
+		before, after = lines[:start], lines[end:]
+	case first != -1:
+		before, after = lines[:first], lines[first:]
+	default:
+		before = lines
+	}
+
+	parts := []string{strings.TrimRight(strings.Join(before, "\n"), "\n"), strings.TrimRight(section, "\n")}
+	if rest := strings.Trim(strings.Join(after, "\n"), "\n"); rest != "" {
+		parts = append(parts, rest)
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// sectionVersion extracts "1.2.0" from "## [1.2.0](...) - 2024-05-01".
+func sectionVersion(heading string) string {
+	heading = strings.TrimSpace(strings.TrimPrefix(heading, "## "))
+	if strings.HasPrefix(heading, "[") {
+		if end := strings.Index(heading, "]"); end != -1 {
+			return heading[1:end]
+		}
+	}
+	name, _, _ := strings.Cut(heading, " ")
+	return name
+}
@@ -0,0 +1,265 @@
+// Package conventional parses and renders Conventional Commits 1.0 messages.
+// See https://www.conventionalcommits.org/en/v1.0.0/.
+package conventional
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	BreakingChangeToken    = "BREAKING CHANGE"
+	BreakingChangeTokenAlt = "BREAKING-CHANGE"
+
+	separatorColon = ": "
+	separatorHash  = " #"
+)
+
+var (
+	headerRegex = regexp.MustCompile(`^([A-Za-z][\w-]*)(?:\(([^()\n]*)\))?(!)?: (.*)$`)
+	footerRegex = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[\w-]+)(: | #)(.*)$`)
+)
+
+type ParseError struct {
+	Reason string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("not a conventional commit: %s", e.Reason)
+}
+
+func (e ParseError) Is(target error) bool {
+	_, ok := target.(ParseError)
+	return ok
+}
+
+// Footer is a git trailer-like line at the end of the message, such as
+// "Refs: #123", "Closes #42" or "BREAKING CHANGE: drop v1".
+type Footer struct {
+	Token     string
+	Separator string
+	Value     string
+}
+
+func (f Footer) String() string {
+	separator := f.Separator
+	if separator == "" {
+		separator = separatorColon
+	}
+	return f.Token + separator + f.Value
+}
+
+func (f Footer) IsBreakingChange() bool {
+	return f.Token == BreakingChangeToken || f.Token == BreakingChangeTokenAlt
+}
+
+type Message struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+	Body        string
+	Footers     []Footer
+}
+
+// Header is the parsed first line of a message.
+type Header struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+// ParseHeader parses only the first line of a message.
+func ParseHeader(line string) (Header, error) {
+	line = strings.TrimRight(line, "\r")
+	matches := headerRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return Header{}, ParseError{Reason: "header must look like `type(scope)!: description`"}
+	}
+
+	header := Header{
+		Type:        matches[1],
+		Scope:       strings.TrimSpace(matches[2]),
+		Breaking:    matches[3] == "!",
+		Description: strings.TrimSpace(matches[4]),
+	}
+	if header.Description == "" {
+		return Header{}, ParseError{Reason: "description is empty"}
+	}
+	if strings.HasPrefix(line[len(header.Type):], "()") {
+		return Header{}, ParseError{Reason: "scope is empty"}
+	}
+	return header, nil
+}
+
+func (h Header) String() string {
+	var b strings.Builder
+	b.WriteString(h.Type)
+	if h.Scope != "" {
+		b.WriteString("(" + h.Scope + ")")
+	}
+	if h.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": " + h.Description)
+	return b.String()
+}
+
+// Parse parses a full commit message. Comment lines are not stripped; clean
+// the message first if it comes straight from an editor.
+func Parse(message string) (*Message, error) {
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+	message = strings.Trim(message, "\n")
+
+	headerLine, rest, _ := strings.Cut(message, "\n")
+	header, err := ParseHeader(headerLine)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		Type:        header.Type,
+		Scope:       header.Scope,
+		Breaking:    header.Breaking,
+		Description: header.Description,
+	}
+
+	if rest == "" {
+		return m, nil
+	}
+	if !strings.HasPrefix(rest, "\n") {
+		return nil, ParseError{Reason: "header must be followed by a blank line"}
+	}
+
+	body, footers := splitFooters(strings.Trim(rest, "\n"))
+	m.Body = body
+	m.Footers = footers
+	return m, nil
+}
+
+// splitFooters separates the footers, which make up the trailing paragraphs
+// whose first lines are footers, from the body. A paragraph that merely looks
+// like a footer stays in the body when plain text follows it.
+func splitFooters(text string) (string, []Footer) {
+	lines := strings.Split(text, "\n")
+
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if i > 0 && lines[i-1] != "" {
+			continue
+		}
+		if !footerRegex.MatchString(lines[i]) {
+			break
+		}
+		start = i
+	}
+	if start == -1 {
+		return text, nil
+	}
+
+	var footers []Footer
+	for _, line := range lines[start:] {
+		if matches := footerRegex.FindStringSubmatch(line); matches != nil {
+			footers = append(footers, Footer{Token: matches[1], Separator: matches[2], Value: matches[3]})
+			continue
+		}
+		// Anything else continues the previous footer's value
+		last := &footers[len(footers)-1]
+		last.Value += "\n" + line
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimRight(footers[i].Value, "\n")
+	}
+
+	body := strings.Trim(strings.Join(lines[:start], "\n"), "\n")
+	return body, footers
+}
+
+func (m *Message) Header() Header {
+	return Header{
+		Type:        m.Type,
+		Scope:       m.Scope,
+		Breaking:    m.Breaking,
+		Description: m.Description,
+	}
+}
+
+// String renders the message; Parse(m.String()) yields an equal message.
+func (m *Message) String() string {
+	parts := []string{m.Header().String()}
+	if m.Body != "" {
+		parts = append(parts, m.Body)
+	}
+	if len(m.Footers) > 0 {
+		footers := make([]string, len(m.Footers))
+		for i, f := range m.Footers {
+			footers[i] = f.String()
+		}
+		parts = append(parts, strings.Join(footers, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// IsBreaking reports whether the message marks a breaking change, either with
+// "!" in the header or with a BREAKING CHANGE footer.
+func (m *Message) IsBreaking() bool {
+	return m.Breaking || m.BreakingChange() != ""
+}
+
+// BreakingChange returns the description of the BREAKING CHANGE footer,
+// falling back to the header description when only "!" is used.
+func (m *Message) BreakingChange() string {
+	for _, f := range m.Footers {
+		if f.IsBreakingChange() {
+			return f.Value
+		}
+	}
+	if m.Breaking {
+		return m.Description
+	}
+	return ""
+}
+
+// Footer returns the value of the first footer with the given token.
+func (m *Message) Footer(token string) (string, bool) {
+	for _, f := range m.Footers {
+		if strings.EqualFold(f.Token, token) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// AddFooter appends a footer unless an identical one is already present.
+func (m *Message) AddFooter(token, value string) {
+	for _, f := range m.Footers {
+		if f.Token == token && f.Value == value {
+			return
+		}
+	}
+	m.Footers = append(m.Footers, Footer{Token: token, Separator: separatorColon, Value: value})
+}
+
+// SetBreakingChange marks the message as breaking with "!" and, if given a
+// description, a BREAKING CHANGE footer, replacing any existing one.
+func (m *Message) SetBreakingChange(description string) {
+	m.ClearBreakingChange()
+	m.Breaking = true
+	if description != "" {
+		m.Footers = append(m.Footers, Footer{Token: BreakingChangeToken, Separator: separatorColon, Value: description})
+	}
+}
+
+// ClearBreakingChange removes the "!" and any BREAKING CHANGE footers.
+func (m *Message) ClearBreakingChange() {
+	m.Breaking = false
+	footers := m.Footers[:0]
+	for _, f := range m.Footers {
+		if !f.IsBreakingChange() {
+			footers = append(footers, f)
+		}
+	}
+	m.Footers = footers
+}
@@ -0,0 +1,247 @@
+package conventional
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Header
+		wantErr bool
+	}{
+		{line: "feat: add refunds", want: Header{Type: "feat", Description: "add refunds"}},
+		{line: "fix(api): handle nil", want: Header{Type: "fix", Scope: "api", Description: "handle nil"}},
+		{line: "feat(api)!: drop v1", want: Header{Type: "feat", Scope: "api", Breaking: true, Description: "drop v1"}},
+		{line: "chore!: bump go\r", want: Header{Type: "chore", Breaking: true, Description: "bump go"}},
+		{line: "feat(): add refunds", wantErr: true},
+		{line: "feat: ", wantErr: true},
+		{line: "feat add refunds", wantErr: true},
+		{line: "feat:add refunds", wantErr: true},
+		{line: "Merge branch 'main'", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := ParseHeader(tt.line)
+			if tt.wantErr {
+				if !errors.Is(err, ParseError{}) {
+					t.Fatalf("ParseHeader() error = %v, want a ParseError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHeader() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    *Message
+		wantErr bool
+	}{
+		{
+			name:    "header only",
+			message: "fix: handle nil\n",
+			want:    &Message{Type: "fix", Description: "handle nil"},
+		},
+		{
+			name:    "body",
+			message: "feat(api): add refunds\n\nRefunds can be partial.\n\nThey are capped at the charge.",
+			want: &Message{
+				Type: "feat", Scope: "api", Description: "add refunds",
+				Body: "Refunds can be partial.\n\nThey are capped at the charge.",
+			},
+		},
+		{
+			name:    "footers",
+			message: "fix: handle nil\n\nExplain.\n\nRefs: #123\nCloses #42\nReviewed-by: Ann",
+			want: &Message{
+				Type: "fix", Description: "handle nil", Body: "Explain.",
+				Footers: []Footer{
+					{Token: "Refs", Separator: ": ", Value: "#123"},
+					{Token: "Closes", Separator: " #", Value: "42"},
+					{Token: "Reviewed-by", Separator: ": ", Value: "Ann"},
+				},
+			},
+		},
+		{
+			name:    "multi-line footer value",
+			message: "feat!: drop v1\n\nBREAKING CHANGE: the v1 API is gone.\nMigrate to v2 first.\nRefs: #7",
+			want: &Message{
+				Type: "feat", Breaking: true, Description: "drop v1",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Separator: ": ", Value: "the v1 API is gone.\nMigrate to v2 first."},
+					{Token: "Refs", Separator: ": ", Value: "#7"},
+				},
+			},
+		},
+		{
+			name:    "footer-like paragraph followed by body",
+			message: "docs: explain config\n\nNote: the file is optional.\n\nIt is read from the repo root.",
+			want: &Message{
+				Type: "docs", Description: "explain config",
+				Body: "Note: the file is optional.\n\nIt is read from the repo root.",
+			},
+		},
+		{
+			name:    "footer-like paragraph before footers",
+			message: "docs: explain config\n\nNote: the file is optional.\n\nRefs: #9",
+			want: &Message{
+				Type: "docs", Description: "explain config",
+				Footers: []Footer{
+					{Token: "Note", Separator: ": ", Value: "the file is optional."},
+					{Token: "Refs", Separator: ": ", Value: "#9"},
+				},
+			},
+		},
+		{
+			name:    "footers only",
+			message: "chore: bump deps\n\nSigned-off-by: Ann <ann@example.com>",
+			want: &Message{
+				Type: "chore", Description: "bump deps",
+				Footers: []Footer{{Token: "Signed-off-by", Separator: ": ", Value: "Ann <ann@example.com>"}},
+			},
+		},
+		{
+			name:    "CRLF line endings",
+			message: "fix: handle nil\r\n\r\nExplain.\r\n",
+			want:    &Message{Type: "fix", Description: "handle nil", Body: "Explain."},
+		},
+		{
+			name:    "missing blank line",
+			message: "fix: handle nil\nExplain.",
+			wantErr: true,
+		},
+		{
+			name:    "not conventional",
+			message: "wip",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.message)
+			if tt.wantErr {
+				if !errors.Is(err, ParseError{}) {
+					t.Fatalf("Parse() error = %v, want a ParseError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+
+			// Parse(m.String()) yields an equal message
+			again, err := Parse(got.String())
+			if err != nil {
+				t.Fatalf("Parse(String()) error = %v", err)
+			}
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("Parse(String()) = %+v, want %+v", again, got)
+			}
+		})
+	}
+}
+
+func TestMessageString(t *testing.T) {
+	m := &Message{
+		Type: "feat", Scope: "api", Breaking: true, Description: "drop v1",
+		Body: "The v1 API is gone.",
+		Footers: []Footer{
+			{Token: "BREAKING CHANGE", Value: "migrate to v2"},
+			{Token: "Closes", Separator: " #", Value: "42"},
+		},
+	}
+	want := "feat(api)!: drop v1\n\nThe v1 API is gone.\n\nBREAKING CHANGE: migrate to v2\nCloses #42"
+	if got := m.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestBreakingChange(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      string
+		wantBreaking bool
+		want         string
+	}{
+		{name: "not breaking", message: "fix: handle nil"},
+		{name: "bang", message: "feat!: drop v1", wantBreaking: true, want: "drop v1"},
+		{name: "footer", message: "feat: drop v1\n\nBREAKING CHANGE: use v2", wantBreaking: true, want: "use v2"},
+		{name: "alternative footer", message: "feat: drop v1\n\nBREAKING-CHANGE: use v2", wantBreaking: true, want: "use v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.message)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := m.IsBreaking(); got != tt.wantBreaking {
+				t.Errorf("IsBreaking() = %v, want %v", got, tt.wantBreaking)
+			}
+			if got := m.BreakingChange(); got != tt.want {
+				t.Errorf("BreakingChange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetBreakingChange(t *testing.T) {
+	m, err := Parse("feat: drop v1\n\nBREAKING-CHANGE: old\nRefs: #7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetBreakingChange("use v2")
+	want := "feat!: drop v1\n\nRefs: #7\nBREAKING CHANGE: use v2"
+	if got := m.String(); got != want {
+		t.Errorf("after SetBreakingChange() = %q, want %q", got, want)
+	}
+
+	m.SetBreakingChange("")
+	want = "feat!: drop v1\n\nRefs: #7"
+	if got := m.String(); got != want {
+		t.Errorf("after SetBreakingChange(\"\") = %q, want %q", got, want)
+	}
+
+	m.ClearBreakingChange()
+	want = "feat: drop v1\n\nRefs: #7"
+	if got := m.String(); got != want {
+		t.Errorf("after ClearBreakingChange() = %q, want %q", got, want)
+	}
+	if m.IsBreaking() {
+		t.Error("IsBreaking() = true after ClearBreakingChange()")
+	}
+}
+
+func TestFooter(t *testing.T) {
+	m, err := Parse("fix: handle nil\n\nRefs: #1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := m.Footer("refs"); !ok || value != "#1" {
+		t.Errorf("Footer(refs) = %q, %v", value, ok)
+	}
+
+	m.AddFooter("Refs", "#1")
+	m.AddFooter("Refs", "#2")
+	want := "fix: handle nil\n\nRefs: #1\nRefs: #2"
+	if got := m.String(); got != want {
+		t.Errorf("after AddFooter() = %q, want %q", got, want)
+	}
+}
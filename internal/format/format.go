@@ -0,0 +1,268 @@
+// Package format tidies generated commit messages with a pipeline of small
+// transformers, for the rules that are easier to enforce in code than to ask
+// the model for.
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+	"github.com/cowboy-bebug/kommit/internal/lint"
+	"github.com/cowboy-bebug/kommit/internal/utils"
+)
+
+const (
+	StripTrailingPeriod = "strip-trailing-period"
+	LowercaseSubject    = "lowercase-subject"
+	MaxSubjectLength    = "max-subject-length"
+	NormalizeBullets    = "normalize-bullets"
+	WrapBody            = "wrap-body"
+	CollapseBlankLines  = "collapse-blank-lines"
+
+	defaultBullet = "-"
+	codeFence     = "```"
+)
+
+// Transformer rewrites a message. It must leave messages it has nothing to
+// say about unchanged.
+type Transformer func(message string, config utils.FormatConfig) string
+
+var transformers = map[string]Transformer{
+	StripTrailingPeriod: stripTrailingPeriod,
+	LowercaseSubject:    lowercaseSubject,
+	MaxSubjectLength:    maxSubjectLength,
+	NormalizeBullets:    normalizeBullets,
+	WrapBody:            wrapBody,
+	CollapseBlankLines:  collapseBlankLines,
+}
+
+// DefaultTransformers is the pipeline used when the config doesn't list one.
+var DefaultTransformers = []string{
+	StripTrailingPeriod,
+	LowercaseSubject,
+	MaxSubjectLength,
+	NormalizeBullets,
+	WrapBody,
+	CollapseBlankLines,
+}
+
+var (
+	bulletRegex = regexp.MustCompile(`^(\s*)[*+•‣◦–—](\s+)`)
+	// A list item's marker, whose text continuation lines are indented to
+	listItemRegex = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)])\s+`)
+)
+
+// Pipeline returns the names of the transformers the config enables, in order.
+func Pipeline(config utils.FormatConfig) ([]string, error) {
+	names := config.Transformers
+	if len(names) == 0 {
+		names = DefaultTransformers
+	}
+
+	var pipeline []string
+	for _, name := range slices.Concat(names, config.Disable) {
+		if _, ok := transformers[name]; !ok {
+			return nil, fmt.Errorf("unknown transformer %q, try one of: %s", name, strings.Join(DefaultTransformers, ", "))
+		}
+	}
+	for _, name := range names {
+		if !slices.Contains(config.Disable, name) {
+			pipeline = append(pipeline, name)
+		}
+	}
+	return pipeline, nil
+}
+
+// Apply runs the message through the config's pipeline.
+func Apply(message string, config utils.FormatConfig) (string, error) {
+	pipeline, err := Pipeline(config)
+	if err != nil {
+		return message, err
+	}
+	for _, name := range pipeline {
+		message = transformers[name](message, config)
+	}
+	return message, nil
+}
+
+func splitSubject(message string) (string, string) {
+	subject, body, _ := strings.Cut(message, "\n")
+	return subject, body
+}
+
+func joinSubject(subject, body string) string {
+	if body == "" {
+		return subject
+	}
+	return subject + "\n" + body
+}
+
+// updateDescription rewrites the subject's description, or the whole subject
+// when it isn't conventional.
+func updateDescription(message string, update func(string) string) string {
+	subject, body := splitSubject(message)
+	header, err := conventional.ParseHeader(subject)
+	if err != nil {
+		return joinSubject(update(subject), body)
+	}
+	if description := update(header.Description); description != "" {
+		header.Description = description
+	}
+	return joinSubject(header.String(), body)
+}
+
+func stripTrailingPeriod(message string, _ utils.FormatConfig) string {
+	return updateDescription(message, func(description string) string {
+		return strings.TrimRight(description, ". ")
+	})
+}
+
+// lowercaseSubject lowercases the first word of a conventional description,
+// unless it looks like a name or acronym such as "README" or "OpenAI".
+func lowercaseSubject(message string, _ utils.FormatConfig) string {
+	subject, body := splitSubject(message)
+	header, err := conventional.ParseHeader(subject)
+	if err != nil {
+		return message
+	}
+
+	word, _, _ := strings.Cut(header.Description, " ")
+	first, size := utf8.DecodeRuneInString(word)
+	if !unicode.IsUpper(first) || strings.IndexFunc(word[size:], func(r rune) bool {
+		return unicode.IsUpper(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+	}) != -1 {
+		return message
+	}
+
+	header.Description = string(unicode.ToLower(first)) + header.Description[size:]
+	return joinSubject(header.String(), body)
+}
+
+// maxSubjectLength cuts a long subject at the last word that fits.
+func maxSubjectLength(message string, config utils.FormatConfig) string {
+	limit := config.SubjectMaxLength
+	if limit <= 0 {
+		limit = lint.DefaultSubjectMaxLength
+	}
+
+	subject, body := splitSubject(message)
+	if utf8.RuneCountInString(subject) <= limit {
+		return message
+	}
+
+	runes := []rune(subject)
+	cut := string(runes[:limit])
+	if runes[limit] != ' ' {
+		if i := strings.LastIndex(cut, " "); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	// Don't leave the subject dangling on a comma or a conjunction's dash
+	cut = strings.TrimRight(cut, " ,;:-–(")
+	if header, err := conventional.ParseHeader(cut); err != nil || header.Description == "" {
+		return message
+	}
+	return joinSubject(cut, body)
+}
+
+func normalizeBullets(message string, config utils.FormatConfig) string {
+	bullet := config.Bullet
+	if bullet == "" {
+		bullet = defaultBullet
+	}
+
+	subject, body := splitSubject(message)
+	lines := strings.Split(body, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			lines[i] = bulletRegex.ReplaceAllString(line, "${1}"+bullet+" ")
+		}
+	}
+	return joinSubject(subject, strings.Join(lines, "\n"))
+}
+
+// wrapBody hard-wraps long body lines, indenting list items' continuation
+// lines under their text. Footers, code blocks and lines without a space to
+// break at, such as long URLs, are left alone.
+func wrapBody(message string, config utils.FormatConfig) string {
+	width := config.BodyWidth
+	if width <= 0 {
+		width = lint.DefaultBodyMaxLineLength
+	}
+
+	if m, err := conventional.Parse(message); err == nil {
+		m.Body = wrapLines(m.Body, width)
+		return m.String()
+	}
+	subject, body := splitSubject(message)
+	return joinSubject(subject, wrapLines(body, width))
+}
+
+func wrapLines(text string, width int) string {
+	var wrapped []string
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inCode = !inCode
+		}
+		if inCode || utf8.RuneCountInString(line) <= width || !strings.Contains(strings.TrimSpace(line), " ") {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		wrapped = append(wrapped, wrapLine(line, width)...)
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+func wrapLine(line string, width int) []string {
+	prefix := listItemRegex.FindString(line)
+	if prefix == "" {
+		prefix = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+	indent := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+
+	var lines []string
+	current := prefix
+	for _, word := range strings.Fields(line[len(prefix):]) {
+		if current != prefix && current != indent &&
+			utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = indent
+		}
+		if current != prefix && current != indent {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+// collapseBlankLines trims trailing whitespace and squeezes runs of blank
+// lines into one, outside code blocks.
+func collapseBlankLines(message string, _ utils.FormatConfig) string {
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inCode = !inCode
+		}
+		if !inCode {
+			line = strings.TrimRight(line, " \t\r")
+			if line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,122 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cowboy-bebug/kommit/internal/utils"
+)
+
+func TestTransformers(t *testing.T) {
+	long := "feat(api): add refunds to the payments API, with partial amounts and a dry run mode"
+
+	tests := []struct {
+		name        string
+		transformer string
+		config      utils.FormatConfig
+		message     string
+		want        string
+	}{
+		{name: "period", transformer: StripTrailingPeriod, message: "fix: handle nil.\n\nBody.", want: "fix: handle nil\n\nBody."},
+		{name: "ellipsis", transformer: StripTrailingPeriod, message: "fix: handle nil...", want: "fix: handle nil"},
+		{name: "only a period", transformer: StripTrailingPeriod, message: "fix: .", want: "fix: ."},
+		{name: "period without a type", transformer: StripTrailingPeriod, message: "Handle nil.", want: "Handle nil"},
+
+		{name: "capitalised", transformer: LowercaseSubject, message: "fix(api): Handle nil", want: "fix(api): handle nil"},
+		{name: "acronym", transformer: LowercaseSubject, message: "docs: README tweaks", want: "docs: README tweaks"},
+		{name: "name", transformer: LowercaseSubject, message: "feat: OpenAI models", want: "feat: OpenAI models"},
+		{name: "identifier", transformer: LowercaseSubject, message: "fix: Config_path default", want: "fix: Config_path default"},
+		{name: "lowercase without a type", transformer: LowercaseSubject, message: "Handle nil", want: "Handle nil"},
+
+		{name: "short subject", transformer: MaxSubjectLength, message: "fix: handle nil", want: "fix: handle nil"},
+		{name: "long subject", transformer: MaxSubjectLength, message: long + "\n\nBody.", want: "feat(api): add refunds to the payments API, with partial amounts and a\n\nBody."},
+		{name: "custom limit", transformer: MaxSubjectLength, config: utils.FormatConfig{SubjectMaxLength: 30}, message: long, want: "feat(api): add refunds to the"},
+		{name: "cut at a comma", transformer: MaxSubjectLength, config: utils.FormatConfig{SubjectMaxLength: 43}, message: long, want: "feat(api): add refunds to the payments API"},
+		{name: "nothing left to keep", transformer: MaxSubjectLength, config: utils.FormatConfig{SubjectMaxLength: 10}, message: long, want: long},
+
+		{name: "bullets", transformer: NormalizeBullets, message: "feat: x\n\n* one\n  • two\n+ three", want: "feat: x\n\n- one\n  - two\n- three"},
+		{name: "custom bullet", transformer: NormalizeBullets, config: utils.FormatConfig{Bullet: "*"}, message: "feat: x\n\n• one\n- two", want: "feat: x\n\n* one\n- two"},
+		{name: "bullets in code", transformer: NormalizeBullets, message: "feat: x\n\n```\n* keep\n```\n* one", want: "feat: x\n\n```\n* keep\n```\n- one"},
+		{name: "bullet subject", transformer: NormalizeBullets, message: "* feat: x", want: "* feat: x"},
+
+		{
+			name:        "wrap",
+			transformer: WrapBody,
+			config:      utils.FormatConfig{BodyWidth: 20},
+			message:     "fix: x\n\nThis paragraph is longer than twenty characters.",
+			want:        "fix: x\n\nThis paragraph is\nlonger than twenty\ncharacters.",
+		},
+		{
+			name:        "wrap list items",
+			transformer: WrapBody,
+			config:      utils.FormatConfig{BodyWidth: 20},
+			message:     "fix: x\n\n- a list item that keeps going\n12. numbered item wraps too",
+			want:        "fix: x\n\n- a list item that\n  keeps going\n12. numbered item\n    wraps too",
+		},
+		{
+			name:        "wrap leaves code, urls and footers",
+			transformer: WrapBody,
+			config:      utils.FormatConfig{BodyWidth: 20},
+			message:     "fix: x\n\n```\ncode that is long enough to wrap\n```\nhttps://example.com/a/very/long/url\n\nRefs: PAY-1, PAY-2, PAY-3, PAY-4, PAY-5",
+			want:        "fix: x\n\n```\ncode that is long enough to wrap\n```\nhttps://example.com/a/very/long/url\n\nRefs: PAY-1, PAY-2, PAY-3, PAY-4, PAY-5",
+		},
+		{
+			name:        "wrap without a type",
+			transformer: WrapBody,
+			config:      utils.FormatConfig{BodyWidth: 20},
+			message:     "Handle nil\n\nThis paragraph is longer than twenty characters.",
+			want:        "Handle nil\n\nThis paragraph is\nlonger than twenty\ncharacters.",
+		},
+
+		{name: "blank lines", transformer: CollapseBlankLines, message: "fix: x  \n\n\n\nBody. \n\n", want: "fix: x\n\nBody."},
+		{name: "blank lines in code", transformer: CollapseBlankLines, message: "fix: x\n\n```\na\n\n\nb  \n```", want: "fix: x\n\n```\na\n\n\nb  \n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transformers[tt.transformer](tt.message, tt.config); got != tt.want {
+				t.Errorf("%s() = %q, want %q", tt.transformer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline, err := Pipeline(utils.FormatConfig{})
+	if err != nil || strings.Join(pipeline, ",") != strings.Join(DefaultTransformers, ",") {
+		t.Errorf("Pipeline() = %q, %v, want the defaults", pipeline, err)
+	}
+
+	pipeline, err = Pipeline(utils.FormatConfig{Disable: []string{LowercaseSubject, WrapBody}})
+	if err != nil || strings.Join(pipeline, ",") != "strip-trailing-period,max-subject-length,normalize-bullets,collapse-blank-lines" {
+		t.Errorf("Pipeline() with disabled transformers = %q, %v", pipeline, err)
+	}
+
+	pipeline, err = Pipeline(utils.FormatConfig{Transformers: []string{WrapBody, StripTrailingPeriod}})
+	if err != nil || strings.Join(pipeline, ",") != "wrap-body,strip-trailing-period" {
+		t.Errorf("Pipeline() with listed transformers = %q, %v", pipeline, err)
+	}
+
+	for _, config := range []utils.FormatConfig{
+		{Transformers: []string{"shout"}},
+		{Disable: []string{"shout"}},
+	} {
+		if _, err := Pipeline(config); err == nil {
+			t.Errorf("Pipeline(%+v) with an unknown transformer should fail", config)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	got, err := Apply("Fix(api): Handle nil.\n\n\n* check the input\n", utils.FormatConfig{})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if want := "Fix(api): handle nil\n\n- check the input"; got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+
+	if got, err := Apply("fix: x", utils.FormatConfig{Transformers: []string{"shout"}}); err == nil || got != "fix: x" {
+		t.Errorf("Apply() with an unknown transformer = %q, %v, want the message back and an error", got, err)
+	}
+}
@@ -0,0 +1,56 @@
+package lint
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+)
+
+// Fix repairs the violations that have one obvious, safe fix: a code block
+// around the message, surrounding blank lines, an upper case type, a trailing
+// period and a missing blank line after the subject. Everything else,
+// including a scope that isn't allowed, is left for the model or the user.
+func Fix(message string, rules Rules) string {
+	lines := strings.Split(strings.Trim(message, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "```") {
+		lines = lines[1:]
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" {
+			lines = lines[:n-1]
+		}
+	}
+	lines = trimBlankLines(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+
+	if header, err := conventional.ParseHeader(lines[0]); err == nil {
+		if lower := strings.ToLower(header.Type); lower != header.Type && slices.Contains(rules.Types, lower) {
+			header.Type = lower
+		}
+		header.Description = strings.TrimRight(header.Description, ".")
+		if header.Description != "" {
+			lines[0] = header.String()
+		}
+	}
+
+	if len(lines) > 1 && lines[1] != "" {
+		lines = slices.Insert(lines, 1, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
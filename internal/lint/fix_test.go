@@ -0,0 +1,42 @@
+package lint
+
+import "testing"
+
+func TestFix(t *testing.T) {
+	rules := DefaultRules([]string{"feat", "fix"}, []string{"api"})
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "clean", message: "feat(api): add refunds\n\nExplain.", want: "feat(api): add refunds\n\nExplain."},
+		{name: "code block", message: "```\nfix: handle nil\n```", want: "fix: handle nil"},
+		{name: "code block with a language", message: "```text\nfix: handle nil\n\nExplain.\n```\n", want: "fix: handle nil\n\nExplain."},
+		{name: "blank lines and trailing spaces", message: "\n\nfix: handle nil  \n\nExplain. \n\n", want: "fix: handle nil\n\nExplain."},
+		{name: "upper case type", message: "FIX: handle nil", want: "fix: handle nil"},
+		{name: "unknown upper case type", message: "CHORE: bump deps", want: "CHORE: bump deps"},
+		{name: "unknown scope", message: "feat(db): add index", want: "feat(db): add index"},
+		{name: "trailing period", message: "fix(api)!: handle nil...", want: "fix(api)!: handle nil"},
+		{name: "only a period", message: "fix: .", want: "fix: ."},
+		{name: "missing blank line", message: "fix: handle nil\nExplain.", want: "fix: handle nil\n\nExplain."},
+		{name: "not conventional", message: "Handle nil.", want: "Handle nil."},
+		{name: "empty", message: "\n\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fix(tt.message, rules); got != tt.want {
+				t.Errorf("Fix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixLeavesScopeForLint(t *testing.T) {
+	rules := DefaultRules([]string{"feat"}, []string{"api"})
+	violations := Lint(Fix("feat(db): add index.", rules), rules)
+	if got := rulesOf(violations); len(got) != 1 || got[0] != "scope-enum" {
+		t.Errorf("Lint(Fix()) rules = %q, want the unknown scope flagged", got)
+	}
+}
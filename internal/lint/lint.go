@@ -0,0 +1,225 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+)
+
+const (
+	DefaultSubjectMaxLength  = 72
+	DefaultBodyMaxLineLength = 72
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`
+	Message  string   `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("line %d: %s (%s)", v.Line, v.Message, v.Rule)
+}
+
+// Rules configures Lint. Empty Types or Scopes allow anything.
+type Rules struct {
+	Types             []string
+	Scopes            []string
+	SubjectMaxLength  int
+	BodyMaxLineLength int
+}
+
+func DefaultRules(types, scopes []string) Rules {
+	return Rules{
+		Types:             types,
+		Scopes:            scopes,
+		SubjectMaxLength:  DefaultSubjectMaxLength,
+		BodyMaxLineLength: DefaultBodyMaxLineLength,
+	}
+}
+
+// DefaultCommentChar starts comment lines unless core.commentChar says
+// otherwise.
+const DefaultCommentChar = "#"
+
+var (
+	scissorsRegex = regexp.MustCompile(`^ -+ >8 -+$`)
+	// Messages git writes itself, which aren't expected to be conventional
+	generatedPrefixes = []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "}
+)
+
+// CleanMessage strips what `git commit` would strip with the default cleanup
+// mode: lines starting with commentChar, everything below a scissors line and
+// surrounding blank lines.
+func CleanMessage(message, commentChar string) string {
+	if commentChar == "" {
+		commentChar = DefaultCommentChar
+	}
+
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if !strings.HasPrefix(line, commentChar) {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+			continue
+		}
+		if scissorsRegex.MatchString(line[len(commentChar):]) {
+			break
+		}
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// IsGeneratedMessage reports whether a message was written by git itself, e.g.
+// for a merge or a fixup, and should not be linted.
+func IsGeneratedMessage(message string) bool {
+	for _, prefix := range generatedPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks a cleaned commit message against the rules.
+func Lint(message string, rules Rules) []Violation {
+	var violations []Violation
+	add := func(rule string, severity Severity, line int, format string, args ...any) {
+		violations = append(violations, Violation{
+			Rule:     rule,
+			Severity: severity,
+			Line:     line,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	lines := strings.Split(message, "\n")
+	subject := lines[0]
+	if strings.TrimSpace(subject) == "" {
+		add("subject-empty", SeverityError, 1, "subject is empty")
+		return violations
+	}
+
+	if strings.HasPrefix(subject, "```") {
+		add("code-fence", SeverityError, 1, "message must not be wrapped in a code block")
+	}
+
+	if rules.SubjectMaxLength > 0 && utf8.RuneCountInString(subject) > rules.SubjectMaxLength {
+		add("subject-max-length", SeverityError, 1, "subject is %d characters long, the limit is %d",
+			utf8.RuneCountInString(subject), rules.SubjectMaxLength)
+	}
+
+	header, err := conventional.ParseHeader(subject)
+	var parseErr conventional.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		add("header-format", SeverityError, 1, "%s", parseErr.Reason)
+	case err == nil:
+		violations = append(violations, lintHeader(header, rules)...)
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		add("body-leading-blank", SeverityError, 2, "subject must be followed by a blank line")
+	}
+
+	for i, line := range lines[1:] {
+		if rules.BodyMaxLineLength <= 0 || utf8.RuneCountInString(line) <= rules.BodyMaxLineLength {
+			continue
+		}
+		// Long URLs and other unbreakable tokens can't be wrapped
+		if !strings.Contains(strings.TrimSpace(line), " ") {
+			continue
+		}
+		add("body-max-line-length", SeverityError, i+2, "line is %d characters long, the limit is %d",
+			utf8.RuneCountInString(line), rules.BodyMaxLineLength)
+	}
+
+	return violations
+}
+
+func lintHeader(header conventional.Header, rules Rules) []Violation {
+	var violations []Violation
+	add := func(rule string, severity Severity, format string, args ...any) {
+		violations = append(violations, Violation{
+			Rule:     rule,
+			Severity: severity,
+			Line:     1,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len(rules.Types) > 0 && !slices.Contains(rules.Types, header.Type) {
+		add("type-enum", SeverityError, "type %q is not one of: %s", header.Type, strings.Join(rules.Types, ", "))
+	}
+
+	if header.Scope != "" && len(rules.Scopes) > 0 && !slices.Contains(rules.Scopes, header.Scope) {
+		add("scope-enum", SeverityError, "scope %q is not one of: %s", header.Scope, strings.Join(rules.Scopes, ", "))
+	}
+
+	description := header.Description
+	if strings.HasSuffix(description, ".") {
+		add("description-full-stop", SeverityError, "description must not end with a period")
+	}
+
+	if word, ok := nonImperative(description); ok {
+		add("description-imperative", SeverityWarning, "description should use the imperative mood (%q)", word)
+	}
+
+	return violations
+}
+
+// Words that look past tense or third person but are fine in the imperative
+var imperativeExceptions = map[string]bool{
+	"address": true, "bias": true, "bless": true, "bypass": true, "focus": true,
+	"embed": true, "feed": true, "access": true, "compress": true, "discuss": true,
+	"pass": true, "process": true, "progress": true, "redesign": true, "shred": true,
+	"speed": true, "suppress": true, "toss": true, "unless": true, "proceed": true,
+	"need": true, "seed": true, "exceed": true, "succeed": true, "press": true,
+	"express": true, "miss": true, "dismiss": true, "redress": true, "canvas": true,
+	"alias": true, "gas": true, "bring": true, "string": true, "ring": true,
+	"sing": true, "swing": true, "thing": true, "wing": true, "fling": true,
+	"sting": true, "wring": true, "spring": true, "cling": true, "ping": true,
+}
+
+// nonImperative guesses whether the first word of a description is in the
+// past tense ("added"), a gerund ("adding") or third person ("adds").
+func nonImperative(description string) (string, bool) {
+	fields := strings.Fields(description)
+	if len(fields) == 0 {
+		return "", false
+	}
+	word := strings.ToLower(strings.Trim(fields[0], "`'\",.:;"))
+	if len(word) < 4 || imperativeExceptions[word] {
+		return word, false
+	}
+	for _, suffix := range []string{"ed", "ing"} {
+		if strings.HasSuffix(word, suffix) {
+			return word, true
+		}
+	}
+	if strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is") {
+		return word, true
+	}
+	return word, false
+}
+
+func HasErrors(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func rulesOf(violations []Violation) []string {
+	rules := make([]string, len(violations))
+	for i, v := range violations {
+		rules[i] = v.Rule
+	}
+	return rules
+}
+
+func TestLint(t *testing.T) {
+	rules := DefaultRules([]string{"feat", "fix"}, []string{"api", "cli"})
+
+	tests := []struct {
+		name    string
+		message string
+		rules   Rules
+		want    []string
+	}{
+		{name: "clean", message: "feat(api): add refunds\n\nExplain why.", rules: rules},
+		{name: "no scope", message: "fix: handle nil", rules: rules},
+		{name: "empty subject", message: "\n\nBody.", rules: rules, want: []string{"subject-empty"}},
+		{name: "code fence", message: "```\nfeat: add refunds\n```", rules: rules, want: []string{"code-fence", "header-format", "body-leading-blank"}},
+		{name: "not conventional", message: "Add refunds", rules: rules, want: []string{"header-format"}},
+		{name: "unknown type", message: "chore: bump deps", rules: rules, want: []string{"type-enum"}},
+		{name: "unknown scope", message: "feat(db): add index", rules: rules, want: []string{"scope-enum"}},
+		{name: "any type or scope", message: "chore(db): bump deps", rules: DefaultRules(nil, nil)},
+		{name: "full stop", message: "fix: handle nil.", rules: rules, want: []string{"description-full-stop"}},
+		{name: "past tense", message: "fix: handled nil", rules: rules, want: []string{"description-imperative"}},
+		{name: "gerund", message: "fix: handling nil", rules: rules, want: []string{"description-imperative"}},
+		{name: "third person", message: "fix: handles nil", rules: rules, want: []string{"description-imperative"}},
+		{name: "imperative exception", message: "fix: address nil", rules: rules},
+		{name: "missing blank line", message: "fix: handle nil\nExplain why.", rules: rules, want: []string{"body-leading-blank"}},
+		{
+			name:    "long subject",
+			message: "fix: " + strings.Repeat("x", DefaultSubjectMaxLength),
+			rules:   rules,
+			want:    []string{"subject-max-length"},
+		},
+		{
+			name:    "long body line",
+			message: "fix: handle nil\n\n" + strings.Repeat("word ", 20),
+			rules:   rules,
+			want:    []string{"body-max-line-length"},
+		},
+		{
+			name:    "long url",
+			message: "fix: handle nil\n\nhttps://example.com/" + strings.Repeat("x", 80),
+			rules:   rules,
+		},
+		{
+			name:    "no length limits",
+			message: "fix: " + strings.Repeat("x", 100),
+			rules:   Rules{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rulesOf(Lint(tt.message, tt.rules))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Lint() rules = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintSeverity(t *testing.T) {
+	rules := DefaultRules(nil, nil)
+	if violations := Lint("fix: handled nil", rules); len(violations) == 0 || HasErrors(violations) {
+		t.Errorf("a non-imperative description should only warn, got %v", violations)
+	}
+	if violations := Lint("fix: handle nil.", rules); !HasErrors(violations) {
+		t.Errorf("a full stop should be an error, got %v", violations)
+	}
+}
+
+func TestCleanMessage(t *testing.T) {
+	message := "\n\nfix: handle nil   \n# Please enter the commit message\n\nExplain.\n\n" +
+		"# ------------------------ >8 ------------------------\n" +
+		"diff --git a/x b/x\n"
+	if got, want := CleanMessage(message, DefaultCommentChar), "fix: handle nil\n\nExplain."; got != want {
+		t.Errorf("CleanMessage() = %q, want %q", got, want)
+	}
+
+	// With core.commentChar=; git keeps lines starting with "#"
+	message = "fix: handle nil\n\n#12 explains.\n; Kommit suspects breaking changes\n;   - api.go: removed exported identifier `Refund`\n" +
+		"; ------------------------ >8 ------------------------\n" +
+		"diff --git a/x b/x\n"
+	if got, want := CleanMessage(message, ";"), "fix: handle nil\n\n#12 explains."; got != want {
+		t.Errorf("CleanMessage() with ; = %q, want %q", got, want)
+	}
+}
+
+func TestIsGeneratedMessage(t *testing.T) {
+	for _, message := range []string{"Merge branch 'main'", "Revert \"feat: x\"", "fixup! feat: x", "squash! feat: x", "amend! feat: x"} {
+		if !IsGeneratedMessage(message) {
+			t.Errorf("IsGeneratedMessage(%q) = false", message)
+		}
+	}
+	if IsGeneratedMessage("feat: merge accounts") {
+		t.Error("IsGeneratedMessage() = true for a conventional message")
+	}
+}
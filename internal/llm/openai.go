@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cowboy-bebug/kommit/internal/breaking"
 	"github.com/cowboy-bebug/kommit/internal/models"
 	"github.com/cowboy-bebug/kommit/internal/utils"
 	"github.com/invopop/jsonschema"
@@ -76,7 +77,15 @@ const (
   - Wrap lines at **72 characters**.
 `
 
-	kommitBaseUserPrompt = promptMain + promptGeneralRules + promptCommitTypeGuidelines + promptScopeRules + promptMessageFormatting
+	promptBreakingChanges = `
+## **Breaking Changes**
+- If the changes break backward compatibility (e.g. remove or rename public APIs, routes, flags or config keys, or change function signatures):
+  - Add ` + "`" + "!" + "`" + ` after the type or scope (e.g. ` + "`" + "feat(api)!: remove v1 endpoint" + "`" + `).
+  - Add a ` + "`" + "BREAKING CHANGE: <description>" + "`" + ` footer saying what breaks and how to migrate.
+- Otherwise, **do not** mark the changes as breaking.
+`
+
+	kommitBaseUserPrompt = promptMain + promptGeneralRules + promptCommitTypeGuidelines + promptScopeRules + promptMessageFormatting + promptBreakingChanges
 )
 
 func newClient() (*openai.Client, error) {
@@ -98,8 +107,11 @@ func newClient() (*openai.Client, error) {
 }
 
 type ChatResult[T any] struct {
-	Message T
-	Cost    models.Cost
+	Message  T
+	Provider string
+	Model    string
+	Usage    models.Usage
+	Cost     models.Cost
 }
 
 func chat(model, prompt string) (ChatResult[string], error) {
@@ -124,8 +136,11 @@ func chat(model, prompt string) (ChatResult[string], error) {
 	}
 
 	return ChatResult[string]{
-		Message: resp.Choices[0].Message.Content,
-		Cost:    models.EstimateCost(model, resp.Usage),
+		Message:  resp.Choices[0].Message.Content,
+		Provider: models.ProviderOpenAI,
+		Model:    model,
+		Usage:    models.NewUsage(resp.Usage),
+		Cost:     models.EstimateCost(model, resp.Usage),
 	}, nil
 }
 
@@ -180,12 +195,56 @@ func chatStructured[T any](model, prompt string, schema openai.ResponseFormatJSO
 	}
 
 	return ChatResult[T]{
-		Message: result,
-		Cost:    models.EstimateCost(model, resp.Usage),
+		Message:  result,
+		Provider: models.ProviderOpenAI,
+		Model:    model,
+		Usage:    models.NewUsage(resp.Usage),
+		Cost:     models.EstimateCost(model, resp.Usage),
 	}, nil
 }
 
 func GenerateCommitMessage(config *utils.Config, diff, userContext string) (ChatResult[string], error) {
+	return chat(config.LLM.Model, buildCommitPrompt(config, diff, userContext))
+}
+
+// RepairCommitMessage asks for the commit message again, showing the model
+// its previous attempt and the problems to fix.
+func RepairCommitMessage(config *utils.Config, diff, userContext, previous string, problems []string) (ChatResult[string], error) {
+	prompt := buildCommitPrompt(config, diff, userContext)
+
+	prompt += "\n## Previous Attempt:\n"
+	prompt += "**Your previous commit message was**:\n"
+	prompt += "```text\n"
+	prompt += previous + "\n"
+	prompt += "```\n"
+	prompt += "**Write it again, fixing these problems and keeping everything else**:\n"
+	for _, problem := range problems {
+		prompt += "- " + problem + "\n"
+	}
+
+	return chat(config.LLM.Model, prompt)
+}
+
+// GenerateSquashMessage asks for one commit message summarising a branch that
+// is about to be squashed, from its commits' messages and its net diff.
+func GenerateSquashMessage(config *utils.Config, commits []string, diff, userContext string) (ChatResult[string], error) {
+	prompt := buildCommitPrompt(config, diff, userContext)
+
+	prompt += "\n## Commits Being Squashed:\n"
+	prompt += "**The diff above is the net result of these commits, oldest first. Write one message for the whole branch**:\n"
+	prompt += "- Describe the net change, not how the branch got there\n"
+	prompt += "- Ignore work-in-progress, fixup and review-feedback commits, and changes that were later undone\n"
+	prompt += "- Do not add `Co-authored-by` or other trailers; they are added automatically\n"
+	for _, commit := range commits {
+		prompt += "```text\n"
+		prompt += commit + "\n"
+		prompt += "```\n"
+	}
+
+	return chat(config.LLM.Model, prompt)
+}
+
+func buildCommitPrompt(config *utils.Config, diff, userContext string) string {
 	prompt := kommitBaseUserPrompt
 
 	// user context
@@ -205,6 +264,21 @@ func GenerateCommitMessage(config *utils.Config, diff, userContext string) (Chat
 	prompt += wrapInCSVCodeBlock(config.Commit.Scopes)
 	prompt += "  - **Note:** If the changes span multiple scopes, do not use a scope in the commit message.\n"
 
+	// context: ticket references from the branch name
+	if references, err := utils.GetBranchReferences(config.Commit.References); err == nil && len(references) > 0 {
+		prompt += "- **Related tickets** _(from the branch name, added to the message automatically)_:\n"
+		prompt += wrapInCSVCodeBlock(references)
+		prompt += "  - **Note:** Do not mention the tickets in the commit message.\n"
+	}
+
+	// context: possible breaking changes
+	if findings := breaking.Detect(diff); len(findings) > 0 {
+		prompt += "- **Possible breaking changes** _(detected automatically, judge whether they really break users)_:\n"
+		for _, finding := range findings {
+			prompt += "  - " + finding.String() + "\n"
+		}
+	}
+
 	// diff
 	prompt += "\n## Git Diff:\n"
 	prompt += "**Based on the following diff**:\n"
@@ -212,7 +286,7 @@ func GenerateCommitMessage(config *utils.Config, diff, userContext string) (Chat
 	prompt += diff + "\n"
 	prompt += "```\n"
 
-	return chat(config.LLM.Model, prompt)
+	return prompt
 }
 
 type Scopes struct {
@@ -247,3 +321,178 @@ func GenerateScopesFromFilenames(model string, filenames, existingScopes []strin
 
 	return result, nil
 }
+
+type HunkGroup struct {
+	Title string `json:"title"`
+	Hunks []int  `json:"hunks"`
+}
+
+type HunkGroups struct {
+	Groups []HunkGroup `json:"groups"`
+}
+
+var StructuredHunkGroupsSchema = GenerateSchema[HunkGroups]()
+
+// GenerateHunkGroups asks the model to group numbered hunks of a diff by the
+// intent behind them, one group per atomic commit.
+func GenerateHunkGroups(model string, hunks, scopes []string) (ChatResult[HunkGroups], error) {
+	prompt := "The following numbered hunks were staged together but may belong to several logically separate commits.\n"
+	prompt += "Group them so that each group is one atomic change with a single intent (e.g. a feature, a fix, a refactor, docs).\n\n"
+
+	prompt += "- Put every hunk in exactly one group\n"
+	prompt += "- Keep hunks that depend on each other in the same group\n"
+	prompt += "- Prefer fewer groups when in doubt\n"
+	prompt += "- Give each group a short title describing its intent\n"
+
+	if len(scopes) > 0 {
+		prompt += "\nThe project uses these scopes:\n"
+		prompt += wrapInCSVCodeBlock(scopes)
+	}
+
+	for i, hunk := range hunks {
+		prompt += fmt.Sprintf("\n## Hunk %d\n", i)
+		prompt += "```diff\n"
+		prompt += hunk + "\n"
+		prompt += "```\n"
+	}
+
+	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:        openai.F("hunk_groups"),
+		Description: openai.F("Groups of hunk numbers, one group per commit."),
+		Schema:      openai.F(StructuredHunkGroupsSchema),
+		Strict:      openai.Bool(true),
+	}
+
+	return chatStructured[HunkGroups](model, prompt, schemaParam)
+}
+
+type PullRequest struct {
+	Title           string   `json:"title"`
+	Summary         string   `json:"summary"`
+	Changes         []string `json:"changes"`
+	Testing         string   `json:"testing"`
+	BreakingChanges string   `json:"breaking_changes"`
+}
+
+type FilledPullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+var (
+	StructuredPullRequestSchema       = GenerateSchema[PullRequest]()
+	StructuredFilledPullRequestSchema = GenerateSchema[FilledPullRequest]()
+)
+
+func buildPullRequestPrompt(commits []string, diff, userContext string) string {
+	prompt := "Write a pull request for the following branch, based on its commits and its combined diff.\n\n"
+	prompt += "- The title is a short, conventional-commit style summary of the whole branch (e.g. `feat(api): add refunds`)\n"
+	prompt += "- Write for reviewers: explain what changes and why, not how each commit got there\n"
+	prompt += "- Use GitHub-flavored markdown in the text\n"
+	prompt += "- **Do not** invent tickets, links, test results or people\n"
+
+	if userContext != "" {
+		prompt += "\n## User Context:\n"
+		prompt += "- " + userContext + "\n"
+	}
+
+	prompt += "\n## Commits:\n"
+	for _, commit := range commits {
+		prompt += "```text\n"
+		prompt += commit + "\n"
+		prompt += "```\n"
+	}
+
+	if findings := breaking.Detect(diff); len(findings) > 0 {
+		prompt += "\n## Possible Breaking Changes _(detected automatically, judge whether they really break users)_:\n"
+		for _, finding := range findings {
+			prompt += "- " + finding.String() + "\n"
+		}
+	}
+
+	prompt += "\n## Git Diff:\n"
+	prompt += "```diff\n"
+	prompt += diff + "\n"
+	prompt += "```\n"
+
+	return prompt
+}
+
+// GeneratePullRequest asks for a pull request title and the sections of its
+// description.
+func GeneratePullRequest(model string, commits []string, diff, userContext string) (ChatResult[PullRequest], error) {
+	prompt := buildPullRequestPrompt(commits, diff, userContext)
+	prompt += "\n## Sections:\n"
+	prompt += "- **summary**: one short paragraph on what the branch does and why\n"
+	prompt += "- **changes**: the notable changes, one short sentence each\n"
+	prompt += "- **testing**: how to verify the changes, from the tests and code in the diff\n"
+	prompt += "- **breaking_changes**: what breaks for users and how to migrate, or an empty string if nothing breaks\n"
+
+	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:        openai.F("pull_request"),
+		Description: openai.F("A pull request title and the sections of its description."),
+		Schema:      openai.F(StructuredPullRequestSchema),
+		Strict:      openai.Bool(true),
+	}
+
+	return chatStructured[PullRequest](model, prompt, schemaParam)
+}
+
+// FillPullRequestTemplate asks for a pull request title and the repo's pull
+// request template filled in.
+func FillPullRequestTemplate(model string, commits []string, diff, userContext, template string) (ChatResult[FilledPullRequest], error) {
+	prompt := buildPullRequestPrompt(commits, diff, userContext)
+	prompt += "\n## Template:\n"
+	prompt += "**Fill in this pull request template for the body**. Keep its headings, order and checklists, "
+	prompt += "replace its placeholders and instructions (including HTML comments) with content, "
+	prompt += "and leave checkboxes unchecked unless the diff proves them:\n"
+	prompt += "```markdown\n"
+	prompt += template + "\n"
+	prompt += "```\n"
+
+	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:        openai.F("pull_request"),
+		Description: openai.F("A pull request title and its body, the filled-in template."),
+		Schema:      openai.F(StructuredFilledPullRequestSchema),
+		Strict:      openai.Bool(true),
+	}
+
+	return chatStructured[FilledPullRequest](model, prompt, schemaParam)
+}
+
+type ReleaseNote struct {
+	Entry int    `json:"entry"`
+	Text  string `json:"text"`
+}
+
+type ReleaseNotes struct {
+	Notes []ReleaseNote `json:"notes"`
+}
+
+var StructuredReleaseNotesSchema = GenerateSchema[ReleaseNotes]()
+
+// PolishReleaseNotes asks the model to rewrite numbered changelog entries,
+// taken from commit subjects, as notes for the project's users.
+func PolishReleaseNotes(model string, entries []string) (ChatResult[ReleaseNotes], error) {
+	prompt := "The following numbered changelog entries were taken from commit subjects.\n"
+	prompt += "Rewrite each one as a release note for the project's users.\n\n"
+
+	prompt += "- Say what changed for users, not how the code changed\n"
+	prompt += "- Keep each note to one sentence, starting with a capital letter and without a trailing period\n"
+	prompt += "- Keep the names of commands, flags, options and APIs exactly as written, in backticks\n"
+	prompt += "- **Do not** invent details that the entry doesn't mention\n"
+	prompt += "- Return a note for every entry, with the entry's number\n\n"
+
+	for i, entry := range entries {
+		prompt += fmt.Sprintf("%d. %s\n", i, entry)
+	}
+
+	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:        openai.F("release_notes"),
+		Description: openai.F("Release notes, one per numbered changelog entry."),
+		Schema:      openai.F(StructuredReleaseNotesSchema),
+		Strict:      openai.Bool(true),
+	}
+
+	return chatStructured[ReleaseNotes](model, prompt, schemaParam)
+}
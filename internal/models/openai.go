@@ -6,6 +6,8 @@ import (
 	"github.com/openai/openai-go"
 )
 
+const ProviderOpenAI = "openai"
+
 const (
 	OpenAIModelGPT4oMini openai.ChatModel = openai.ChatModelGPT4oMini
 	OpenAIModelGPT4o     openai.ChatModel = openai.ChatModelGPT4o
@@ -64,6 +66,20 @@ var OpenAISupportedModels = []openai.ChatModel{
 	OpenAIModelO3Mini,
 }
 
+type Usage struct {
+	PromptTokens     int64
+	CachedTokens     int64
+	CompletionTokens int64
+}
+
+func NewUsage(usage openai.CompletionUsage) Usage {
+	return Usage{
+		PromptTokens:     usage.PromptTokens,
+		CachedTokens:     usage.PromptTokensDetails.CachedTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+}
+
 func EstimateCost(model openai.ChatModel, usage openai.CompletionUsage) Cost {
 	cost := OpenAIModelCosts[model]
 	estimatedCost := float64(cost.Input)*float64(usage.PromptTokens) +
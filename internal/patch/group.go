@@ -0,0 +1,152 @@
+package patch
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Unit is the smallest piece of a diff that can be committed on its own: a
+// single hunk, or a whole file when the file can't be split.
+type Unit struct {
+	File *File
+	// Hunk is the index into File.Hunks, or -1 for the whole file
+	Hunk int
+}
+
+func (u Unit) IsWhole() bool {
+	return u.Hunk < 0
+}
+
+func (u Unit) String() string {
+	if u.IsWhole() {
+		return u.File.Path()
+	}
+	return fmt.Sprintf("%s %s", u.File.Path(), strings.TrimSpace(u.File.Hunks[u.Hunk].Header()))
+}
+
+// Diff renders the unit on its own, for previews and prompts.
+func (u Unit) Diff() string {
+	if u.IsWhole() {
+		return u.File.String()
+	}
+	return strings.Join(u.File.Header, "\n") + "\n" + u.File.Hunks[u.Hunk].String()
+}
+
+// Units breaks files into units, in diff order.
+func Units(files []*File) []Unit {
+	var units []Unit
+	for _, f := range files {
+		if f.Whole {
+			units = append(units, Unit{File: f, Hunk: -1})
+			continue
+		}
+		for i := range f.Hunks {
+			units = append(units, Unit{File: f, Hunk: i})
+		}
+	}
+	return units
+}
+
+type Group struct {
+	Title string
+	Units []Unit
+}
+
+// GroupByFile puts every file's units into a group of their own.
+func GroupByFile(units []Unit) []Group {
+	return groupBy(units, func(u Unit) string {
+		return u.File.Path()
+	})
+}
+
+// GroupByScope groups units by the scope their path belongs to, matching
+// path components against the given scopes. The deepest match wins; units
+// outside every scope end up in a group of their own.
+func GroupByScope(units []Unit, scopes []string) []Group {
+	return groupBy(units, func(u Unit) string {
+		return ScopeOf(u.File.Path(), scopes)
+	})
+}
+
+// ScopeOf returns the scope the path belongs to, or "" if none matches.
+func ScopeOf(filePath string, scopes []string) string {
+	components := strings.Split(path.Dir(filePath), "/")
+	base := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	components = append(components, base)
+
+	for i := len(components) - 1; i >= 0; i-- {
+		if slices.Contains(scopes, components[i]) {
+			return components[i]
+		}
+	}
+	return ""
+}
+
+func groupBy(units []Unit, key func(Unit) string) []Group {
+	var groups []Group
+	index := make(map[string]int)
+	for _, u := range units {
+		k := key(u)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			title := k
+			if title == "" {
+				title = "(no scope)"
+			}
+			groups = append(groups, Group{Title: title})
+		}
+		groups[i].Units = append(groups[i].Units, u)
+	}
+	return groups
+}
+
+// Series renders one patch per group. Each patch applies on top of the ones
+// before it, so applying them in order reproduces the original diff.
+func Series(groups []Group) []string {
+	// Which group each hunk of each file went to
+	assigned := make(map[*File][]int)
+	var files []*File
+	for g, group := range groups {
+		for _, u := range group.Units {
+			if _, ok := assigned[u.File]; !ok {
+				assigned[u.File] = make([]int, max(len(u.File.Hunks), 1))
+				for i := range assigned[u.File] {
+					assigned[u.File][i] = -1
+				}
+				files = append(files, u.File)
+			}
+			if u.IsWhole() {
+				for i := range assigned[u.File] {
+					assigned[u.File][i] = g
+				}
+				continue
+			}
+			assigned[u.File][u.Hunk] = g
+		}
+	}
+
+	patches := make([]string, len(groups))
+	for g := range groups {
+		var b strings.Builder
+		for _, f := range files {
+			hunks := assigned[f]
+			if !slices.Contains(hunks, g) {
+				continue
+			}
+			if f.Whole {
+				b.WriteString(f.String())
+				continue
+			}
+			b.WriteString(f.Patch(
+				func(i int) bool { return hunks[i] == g },
+				func(i int) bool { return hunks[i] >= 0 && hunks[i] < g },
+			))
+		}
+		patches[g] = b.String()
+	}
+	return patches
+}
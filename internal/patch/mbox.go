@@ -0,0 +1,123 @@
+package patch
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// The "From " line that starts every message in an mbox, e.g.
+	// "From 1b2c3d... Mon Sep 17 00:00:00 2001" from `git format-patch`
+	mboxFromRegex = regexp.MustCompile(`^From \S+ +\w{3} \w{3} +\d+ \d+:\d+:\d+ \d{4}`)
+	// "[PATCH v2 3/7] " and friends in front of an emailed subject
+	subjectTagRegex = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)+`)
+)
+
+// Mail is a patch sent as an email, e.g. one message of a `git format-patch`
+// series.
+type Mail struct {
+	Subject string
+	Diff    string
+}
+
+// IsMbox reports whether text is a mailbox of patches rather than a bare diff.
+func IsMbox(text string) bool {
+	first, _, _ := strings.Cut(strings.TrimLeft(text, "\n"), "\n")
+	return mboxFromRegex.MatchString(first)
+}
+
+// SplitMbox splits a mailbox into its patches, skipping messages without a
+// diff such as a series' cover letter.
+func SplitMbox(text string) []Mail {
+	var mails []Mail
+	var current []string
+	flush := func() {
+		if mail, ok := parseMail(current); ok {
+			mails = append(mails, mail)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if mboxFromRegex.MatchString(line) && current != nil {
+			flush()
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return mails
+}
+
+func parseMail(lines []string) (Mail, bool) {
+	var mail Mail
+
+	// Headers run up to the first blank line; long ones fold onto
+	// indented continuation lines
+	body := len(lines)
+	inSubject := false
+	for i, line := range lines {
+		if line == "" {
+			body = i + 1
+			break
+		}
+		switch {
+		case inSubject && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			mail.Subject += " " + strings.TrimSpace(line)
+			continue
+		case strings.HasPrefix(strings.ToLower(line), "subject:"):
+			mail.Subject = strings.TrimSpace(line[len("subject:"):])
+			inSubject = true
+			continue
+		}
+		inSubject = false
+	}
+	mail.Subject = subjectTagRegex.ReplaceAllString(mail.Subject, "")
+
+	start := -1
+	end := len(lines)
+	// Lines left in the current hunk, by its header's counts. Inside a hunk,
+	// "-- " is a removed "- " line rather than git's signature separator.
+	oldLeft, newLeft := 0, 0
+	for i := body; i < len(lines); i++ {
+		line := lines[i]
+		if start == -1 {
+			if strings.HasPrefix(line, "diff --git ") {
+				start = i
+			}
+			continue
+		}
+
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file" counts for neither side
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		if matches := hunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+			oldLeft = atoiDefault(matches[2], 1)
+			newLeft = atoiDefault(matches[4], 1)
+			continue
+		}
+		// git's signature separator ends the patch
+		if line == "-- " {
+			end = i
+			break
+		}
+	}
+	if start == -1 {
+		return Mail{}, false
+	}
+
+	mail.Diff = strings.TrimRight(strings.Join(lines[start:end], "\n"), "\n") + "\n"
+	return mail, true
+}
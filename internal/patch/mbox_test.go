@@ -0,0 +1,110 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+)
+
+// signature is the trailer git format-patch ends every mail with. Its "-- "
+// separator keeps its trailing space, which raw strings would hide.
+const signature = "-- \n2.45.0\n"
+
+const coverLetter = `From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001
+From: Ann <ann@example.com>
+Date: Wed, 1 May 2024 10:00:00 +0000
+Subject: [PATCH 0/2] Refunds
+
+Two patches for refunds.
+
+` + signature + "\n"
+
+const firstPatch = `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Ann <ann@example.com>
+Date: Wed, 1 May 2024 10:00:00 +0000
+Subject: [PATCH v2 1/2] Add refunds to the API, with a subject long enough
+ to fold
+
+Some explanation.
+---
+ api.go | 1 +
+ 1 file changed, 1 insertion(+)
+
+diff --git a/api.go b/api.go
+index 1111111..2222222 100644
+--- a/api.go
++++ b/api.go
+@@ -1,2 +1,3 @@
+ package api
++func Refund() {}
+ // end
+` + signature + "\n"
+
+// The removed line is "- ", which shows up in the diff as "-- " just like the
+// signature separator
+const secondPatch = `From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001
+From: Ann <ann@example.com>
+Date: Wed, 1 May 2024 10:01:00 +0000
+Subject: [PATCH v2 2/2] Drop an empty list item
+
+---
+ notes.md | 2 --
+ 1 file changed, 2 deletions(-)
+
+diff --git a/notes.md b/notes.md
+index 3333333..4444444 100644
+--- a/notes.md
++++ b/notes.md
+@@ -1,4 +1,2 @@
+ # Notes
+` + "-- \n" +
+	"-- \n" +
+	" - refunds\n" +
+	signature
+
+func TestIsMbox(t *testing.T) {
+	if !IsMbox("\n" + firstPatch) {
+		t.Error("IsMbox() = false for a format-patch mbox")
+	}
+	if IsMbox(multiHunkDiff) {
+		t.Error("IsMbox() = true for a bare diff")
+	}
+	if IsMbox("From here on, things change\n") {
+		t.Error("IsMbox() = true for prose")
+	}
+}
+
+func TestSplitMbox(t *testing.T) {
+	mails := SplitMbox(coverLetter + firstPatch + secondPatch)
+	if len(mails) != 2 {
+		t.Fatalf("SplitMbox() returned %d mails, want 2 (the cover letter has no diff)", len(mails))
+	}
+
+	if want := "Add refunds to the API, with a subject long enough to fold"; mails[0].Subject != want {
+		t.Errorf("subject = %q, want %q", mails[0].Subject, want)
+	}
+	wantDiff := firstPatch[strings.Index(firstPatch, "diff --git"):strings.Index(firstPatch, "-- \n")]
+	if mails[0].Diff != wantDiff {
+		t.Errorf("diff = %q, want %q", mails[0].Diff, wantDiff)
+	}
+
+	if want := "Drop an empty list item"; mails[1].Subject != want {
+		t.Errorf("subject = %q, want %q", mails[1].Subject, want)
+	}
+	wantDiff = "diff --git a/notes.md b/notes.md\n" +
+		"index 3333333..4444444 100644\n" +
+		"--- a/notes.md\n" +
+		"+++ b/notes.md\n" +
+		"@@ -1,4 +1,2 @@\n" +
+		" # Notes\n" +
+		"-- \n" +
+		"-- \n" +
+		" - refunds\n"
+	if mails[1].Diff != wantDiff {
+		t.Errorf("diff = %q, want %q", mails[1].Diff, wantDiff)
+	}
+
+	files, err := Parse(mails[1].Diff)
+	if err != nil || len(files) != 1 || len(files[0].Hunks[0].Lines) != 4 {
+		t.Errorf("the split diff doesn't parse into the full hunk: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package patch parses `git diff` output into files and hunks, and renders
+// subsets of hunks back into patches that `git apply` accepts.
+package patch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the function context git prints after the line numbers
+	Section string
+	// Lines are the hunk's body lines, each prefixed with ' ', '+', '-' or '\'
+	Lines []string
+}
+
+// Delta is how many lines the hunk adds (or removes, if negative).
+func (h Hunk) Delta() int {
+	return h.NewLines - h.OldLines
+}
+
+func (h Hunk) header(oldStart, newStart int) string {
+	return fmt.Sprintf("@@ -%s +%s @@%s", hunkRange(oldStart, h.OldLines), hunkRange(newStart, h.NewLines), h.Section)
+}
+
+func (h Hunk) Header() string {
+	return h.header(h.OldStart, h.NewStart)
+}
+
+func (h Hunk) String() string {
+	return h.Header() + "\n" + strings.Join(h.Lines, "\n") + "\n"
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+type File struct {
+	OldPath string
+	NewPath string
+	// Header holds everything from "diff --git" up to the first hunk
+	Header []string
+	Hunks  []Hunk
+	// Whole files (new, deleted, renamed, binary or mode-only changes) can't
+	// be split and are always applied in one piece
+	Whole bool
+}
+
+func (f *File) Path() string {
+	if f.NewPath != "" && f.NewPath != "/dev/null" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+func (f *File) String() string {
+	var b strings.Builder
+	b.WriteString(strings.Join(f.Header, "\n") + "\n")
+	for _, h := range f.Hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Patch renders the file with only the selected hunks, against a base where
+// the applied hunks are already in place. Line numbers are adjusted for both,
+// so the result applies cleanly with `git apply`.
+func (f *File) Patch(selected, applied func(i int) bool) string {
+	var b strings.Builder
+	b.WriteString(strings.Join(f.Header, "\n") + "\n")
+
+	// Lines shifted by hunks above: all of them in the original diff, those
+	// already in the base, and those added by this patch
+	var allShift, baseShift, patchShift int
+	for i, h := range f.Hunks {
+		switch {
+		case applied(i):
+			baseShift += h.Delta()
+		case selected(i):
+			oldStart := h.OldStart + baseShift
+			// Keeps git's convention of numbering empty ranges from the line
+			// before, which the original new start already accounts for
+			newStart := oldStart + patchShift + (h.NewStart - h.OldStart - allShift)
+			b.WriteString(h.header(oldStart, newStart) + "\n")
+			b.WriteString(strings.Join(h.Lines, "\n") + "\n")
+			patchShift += h.Delta()
+		}
+		allShift += h.Delta()
+	}
+	return b.String()
+}
+
+// Parse parses the output of `git diff` (optionally with --binary).
+func Parse(diff string) ([]*File, error) {
+	var files []*File
+	var file *File
+	var hunk *Hunk
+
+	flushHunk := func() {
+		if file != nil && hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk = nil
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushHunk()
+			file = &File{Header: []string{line}}
+			files = append(files, file)
+			continue
+		case file == nil:
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("unexpected line before the first file: %q", line)
+		}
+
+		if matches := hunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+			flushHunk()
+			hunk = &Hunk{
+				OldStart: atoi(matches[1]),
+				OldLines: atoiDefault(matches[2], 1),
+				NewStart: atoi(matches[3]),
+				NewLines: atoiDefault(matches[4], 1),
+				Section:  matches[5],
+			}
+			continue
+		}
+
+		if hunk != nil {
+			hunk.Lines = append(hunk.Lines, line)
+			continue
+		}
+
+		file.Header = append(file.Header, line)
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			file.OldPath = trimPathPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ "):
+			file.NewPath = trimPathPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "new file mode"),
+			strings.HasPrefix(line, "deleted file mode"),
+			strings.HasPrefix(line, "rename from"),
+			strings.HasPrefix(line, "copy from"),
+			strings.HasPrefix(line, "old mode"),
+			strings.HasPrefix(line, "GIT binary patch"),
+			strings.HasPrefix(line, "Binary files "):
+			file.Whole = true
+		}
+	}
+	flushHunk()
+
+	for _, f := range files {
+		if f.OldPath == "" && f.NewPath == "" {
+			f.OldPath, f.NewPath = pathsFromDiffLine(f.Header[0])
+		}
+		if len(f.Hunks) <= 1 {
+			f.Whole = true
+		}
+	}
+
+	return files, nil
+}
+
+func trimPathPrefix(path, prefix string) string {
+	path = strings.TrimSuffix(path, "\t")
+	if path == "/dev/null" {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// pathsFromDiffLine extracts paths for diffs without ---/+++ lines, such as
+// pure renames and mode changes.
+func pathsFromDiffLine(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return strings.TrimPrefix(rest[:i], "a/"), rest[i+3:]
+	}
+	return rest, rest
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	return atoi(s)
+}
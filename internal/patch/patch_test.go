@@ -0,0 +1,293 @@
+package patch
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const multiHunkDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -2,3 +2,4 @@ package main
+ a
++b
+ c
+ d
+@@ -10,4 +11,3 @@ func main() {
+ x
+-y
+ z
+ w
+@@ -20 +20,2 @@ func helper() {
+ end
++more
+diff --git a/docs/new.md b/docs/new.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1 @@
++hello
+`
+
+func TestParse(t *testing.T) {
+	files, err := Parse(multiHunkDiff)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Parse() returned %d files, want 2", len(files))
+	}
+
+	main := files[0]
+	if main.Path() != "main.go" || main.Whole || len(main.Hunks) != 3 {
+		t.Errorf("main.go = %q, whole %v, %d hunks", main.Path(), main.Whole, len(main.Hunks))
+	}
+	want := []Hunk{
+		{OldStart: 2, OldLines: 3, NewStart: 2, NewLines: 4},
+		{OldStart: 10, OldLines: 4, NewStart: 11, NewLines: 3},
+		{OldStart: 20, OldLines: 1, NewStart: 20, NewLines: 2},
+	}
+	for i, h := range main.Hunks {
+		if h.OldStart != want[i].OldStart || h.OldLines != want[i].OldLines || h.NewStart != want[i].NewStart || h.NewLines != want[i].NewLines {
+			t.Errorf("hunk %d = %s, want %s", i, h.Header(), want[i].Header())
+		}
+	}
+	if got := main.String(); got != multiHunkDiff[:strings.Index(multiHunkDiff, "diff --git a/docs")] {
+		t.Errorf("String() doesn't round-trip:\n%s", got)
+	}
+
+	added := files[1]
+	if added.Path() != "docs/new.md" || !added.Whole {
+		t.Errorf("docs/new.md = %q, whole %v", added.Path(), added.Whole)
+	}
+
+	units := Units(files)
+	if len(units) != 4 || !units[3].IsWhole() {
+		t.Errorf("Units() = %v, want three hunks and a whole file", units)
+	}
+}
+
+func TestFilePatch(t *testing.T) {
+	files, err := Parse(multiHunkDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := files[0]
+	only := func(indexes ...int) func(int) bool {
+		return func(i int) bool {
+			for _, index := range indexes {
+				if i == index {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	tests := []struct {
+		name              string
+		selected, applied func(int) bool
+		want              []string
+	}{
+		{
+			name:     "last hunk alone",
+			selected: only(2), applied: only(),
+			want: []string{"@@ -20 +20,2 @@ func helper() {"},
+		},
+		{
+			name:     "last hunk after the first",
+			selected: only(2), applied: only(0),
+			want: []string{"@@ -21 +21,2 @@ func helper() {"},
+		},
+		{
+			name:     "second hunk after the third",
+			selected: only(1), applied: only(2),
+			want: []string{"@@ -10,4 +10,3 @@ func main() {"},
+		},
+		{
+			name:     "first and last hunks",
+			selected: only(0, 2), applied: only(),
+			want: []string{"@@ -2,3 +2,4 @@ package main", "@@ -20 +21,2 @@ func helper() {"},
+		},
+		{
+			name:     "all hunks after none",
+			selected: only(0, 1, 2), applied: only(),
+			want: []string{"@@ -2,3 +2,4 @@ package main", "@@ -10,4 +11,3 @@ func main() {", "@@ -20 +20,2 @@ func helper() {"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range strings.Split(f.Patch(tt.selected, tt.applied), "\n") {
+				if strings.HasPrefix(line, "@@ ") {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Patch() headers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// git runs git in the current directory, failing the test on error.
+func git(t *testing.T, input string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Kommit Test", "GIT_AUTHOR_EMAIL=test@kommit.dev",
+		"GIT_COMMITTER_NAME=Kommit Test", "GIT_COMMITTER_EMAIL=test@kommit.dev",
+		"GIT_CONFIG_GLOBAL="+os.DevNull, "GIT_CONFIG_NOSYSTEM=1",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// numbered returns count lines "prefix 1" to "prefix count".
+func numbered(prefix string, count int) []string {
+	lines := make([]string, count)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s %d", prefix, i+1)
+	}
+	return lines
+}
+
+func joinLines(lines []string) []byte {
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// stagedDiff builds a repository whose staged changes touch several hunks in
+// several files, plus new, deleted and binary files. It returns the staged
+// diff and the tree it produces, with the index reset to HEAD.
+func stagedDiff(t *testing.T) (string, string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	git(t, "", "init", "-q")
+
+	long := numbered("line", 60)
+	other := numbered("other", 40)
+	writeFile(t, "long.txt", joinLines(long))
+	writeFile(t, "other.txt", joinLines(other))
+	writeFile(t, "gone.txt", []byte("bye\n"))
+	writeFile(t, "image.bin", []byte{0, 1, 2, 3, 0, 255})
+	git(t, "", "add", "-A")
+	git(t, "", "commit", "-q", "-m", "base")
+
+	// Additions, removals and replacements, with hunks far enough apart to
+	// stay separate
+	var changed []string
+	for i, line := range long {
+		switch i {
+		case 2:
+			changed = append(changed, line, "added a", "added b")
+		case 20, 21, 22:
+			// removed
+		case 40:
+			changed = append(changed, "replaced 41")
+		default:
+			changed = append(changed, line)
+		}
+	}
+	changed = append(changed, "appended")
+	writeFile(t, "long.txt", joinLines(changed))
+
+	other = append([]string{"prepended"}, other...)
+	other[30] = "changed other 30"
+	writeFile(t, "other.txt", joinLines(other))
+
+	writeFile(t, "new.txt", []byte("brand new\n"))
+	if err := os.Remove("gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "image.bin", []byte{0, 9, 8, 7, 0, 255, 254})
+
+	git(t, "", "add", "-A")
+	diff := git(t, "", "diff", "--cached", "--binary") + "\n"
+	tree := git(t, "", "write-tree")
+	git(t, "", "reset", "-q")
+	return diff, tree
+}
+
+func TestSeriesAppliesToOriginalTree(t *testing.T) {
+	diff, want := stagedDiff(t)
+	files, err := Parse(diff)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	units := Units(files)
+
+	hunks := 0
+	for _, u := range units {
+		if !u.IsWhole() {
+			hunks++
+		}
+	}
+	if len(files) != 5 || hunks < 6 {
+		t.Fatalf("staged diff has %d files and %d hunks, want 5 files and at least 6 hunks:\n%s", len(files), hunks, diff)
+	}
+
+	reversed := make([]Unit, len(units))
+	for i, u := range units {
+		reversed[len(units)-1-i] = u
+	}
+	var even, odd []Unit
+	for i, u := range units {
+		if i%2 == 0 {
+			even = append(even, u)
+		} else {
+			odd = append(odd, u)
+		}
+	}
+
+	groupings := map[string][]Group{
+		"one group":          {{Units: units}},
+		"by file":            GroupByFile(units),
+		"one unit per group": singles(units),
+		"reversed":           singles(reversed),
+		"interleaved":        {{Units: odd}, {Units: even}},
+	}
+
+	for name, groups := range groupings {
+		t.Run(name, func(t *testing.T) {
+			git(t, "", "reset", "-q")
+			for i, p := range Series(groups) {
+				if p == "" {
+					continue
+				}
+				cmd := exec.Command("git", "apply", "--cached", "-")
+				cmd.Stdin = strings.NewReader(p)
+				if output, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("patch %d doesn't apply: %v\n%s\n%s", i+1, err, output, p)
+				}
+			}
+			if got := git(t, "", "write-tree"); got != want {
+				t.Errorf("applying the series gives tree %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func singles(units []Unit) []Group {
+	groups := make([]Group, len(units))
+	for i, u := range units {
+		groups[i] = Group{Units: []Unit{u}}
+	}
+	return groups
+}
@@ -0,0 +1,153 @@
+// Package semver parses, orders and bumps semantic versions.
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+var versionRegex = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Prerelease holds the dot-separated identifiers after "-", e.g. rc.1
+	Prerelease []string
+}
+
+// Parse parses a version such as 1.2.3 or 1.2.3-rc.1. Build metadata is
+// accepted and dropped, since it doesn't affect precedence.
+func Parse(s string) (Version, error) {
+	matches := versionRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return Version{}, fmt.Errorf("%q is not a semantic version", s)
+	}
+	v := Version{}
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	v.Patch, _ = strconv.Atoi(matches[3])
+	if matches[4] != "" {
+		v.Prerelease = strings.Split(matches[4], ".")
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Core is the version without its pre-release.
+func (v Version) Core() Version {
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// Compare orders versions by semver precedence, returning -1, 0 or 1.
+func Compare(a, b Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// A pre-release comes before its release
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifiers(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a.Prerelease) - len(b.Prerelease))
+}
+
+// Numeric identifiers sort numerically and before alphanumeric ones
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// Bump returns the next release version. Pre-releases are dropped.
+func (v Version) Bump(bump Bump) Version {
+	next := v.Core()
+	switch bump {
+	case BumpMajor:
+		next = Version{Major: v.Major + 1}
+	case BumpMinor:
+		next = Version{Major: v.Major, Minor: v.Minor + 1}
+	case BumpPatch:
+		next.Patch++
+	}
+	return next
+}
+
+// WithPrerelease returns the next pre-release of v with the given identifier,
+// e.g. 1.3.0-rc.1, or 1.3.0-rc.3 when latest is 1.3.0-rc.2.
+func (v Version) WithPrerelease(id string, latest Version) Version {
+	next := v.Core()
+	number := 1
+	if Compare(latest.Core(), next) == 0 && len(latest.Prerelease) == 2 && latest.Prerelease[0] == id {
+		if n, err := strconv.Atoi(latest.Prerelease[1]); err == nil {
+			number = n + 1
+		}
+	}
+	next.Prerelease = []string{id, strconv.Itoa(number)}
+	return next
+}
@@ -0,0 +1,125 @@
+package semver
+
+import (
+	"slices"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", s, err)
+	}
+	return v
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "1.2.3", want: "1.2.3"},
+		{input: "0.0.0", want: "0.0.0"},
+		{input: "1.2.3-rc.1", want: "1.2.3-rc.1"},
+		{input: "1.2.3-alpha-1.x", want: "1.2.3-alpha-1.x"},
+		{input: "1.2.3+build.7", want: "1.2.3"},
+		{input: "1.2.3-rc.1+build.7", want: "1.2.3-rc.1"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.input).String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "1.2", "v1.2.3", "1.2.3.4", "1.2.3-", "1.2.x"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// In ascending order, as in the semver spec's precedence example
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := Compare(mustParse(t, a), mustParse(t, b)); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+
+	if Compare(mustParse(t, "1.0.0+a"), mustParse(t, "1.0.0+b")) != 0 {
+		t.Error("build metadata should not affect precedence")
+	}
+
+	versions := []Version{mustParse(t, "1.0.0"), mustParse(t, "1.0.0-rc.2"), mustParse(t, "0.1.0")}
+	slices.SortFunc(versions, Compare)
+	if versions[0].String() != "0.1.0" || versions[2].String() != "1.0.0" {
+		t.Errorf("sorted versions = %v", versions)
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    Bump
+		want    string
+	}{
+		{version: "1.2.3", bump: BumpMajor, want: "2.0.0"},
+		{version: "1.2.3", bump: BumpMinor, want: "1.3.0"},
+		{version: "1.2.3", bump: BumpPatch, want: "1.2.4"},
+		{version: "1.2.3", bump: BumpNone, want: "1.2.3"},
+		{version: "0.2.3", bump: BumpMajor, want: "1.0.0"},
+		{version: "1.2.3-rc.1", bump: BumpPatch, want: "1.2.4"},
+		{version: "1.2.3-rc.1", bump: BumpNone, want: "1.2.3"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.version).Bump(tt.bump).String(); got != tt.want {
+			t.Errorf("%s.Bump(%s) = %s, want %s", tt.version, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestWithPrerelease(t *testing.T) {
+	tests := []struct {
+		version string
+		id      string
+		latest  string
+		want    string
+	}{
+		{version: "1.3.0", id: "rc", latest: "1.2.0", want: "1.3.0-rc.1"},
+		{version: "1.3.0", id: "rc", latest: "1.3.0-rc.2", want: "1.3.0-rc.3"},
+		{version: "1.3.0", id: "rc", latest: "1.3.0-beta.4", want: "1.3.0-rc.1"},
+		{version: "1.3.0", id: "rc", latest: "1.2.0-rc.4", want: "1.3.0-rc.1"},
+		{version: "1.3.0", id: "rc", latest: "1.3.0-rc", want: "1.3.0-rc.1"},
+		{version: "1.3.0-rc.5", id: "beta", latest: "0.0.0", want: "1.3.0-beta.1"},
+	}
+	for _, tt := range tests {
+		got := mustParse(t, tt.version).WithPrerelease(tt.id, mustParse(t, tt.latest)).String()
+		if got != tt.want {
+			t.Errorf("%s.WithPrerelease(%s, %s) = %s, want %s", tt.version, tt.id, tt.latest, got, tt.want)
+		}
+	}
+}
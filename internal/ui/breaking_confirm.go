@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type BreakingConfirm struct {
+	findings  []string
+	suspected bool
+	choices   []string
+	cursor    int
+	// describing is set once the change is confirmed as breaking
+	describing  bool
+	description textinput.Model
+	breaking    bool
+	quit        bool
+}
+
+func NewBreakingConfirm(findings []string, suspected bool, description string) *BreakingConfirm {
+	input := textinput.New()
+	input.Placeholder = "What breaks, and how do users migrate?"
+	input.SetValue(description)
+	input.Width = 72
+
+	cursor := 1
+	if suspected {
+		cursor = 0
+	}
+
+	return &BreakingConfirm{
+		findings:  findings,
+		suspected: suspected,
+		choices: []string{
+			"Yes, it's a breaking change " + KeyStyle.Render("(mark it)"),
+			"No, nothing breaks " + KeyStyle.Render("(don't mark it)"),
+		},
+		cursor:      cursor,
+		description: input,
+	}
+}
+
+func (m *BreakingConfirm) Init() tea.Cmd {
+	return nil
+}
+
+func (m *BreakingConfirm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			m.quit = true
+			return m, tea.Quit
+		case "enter":
+			if m.describing {
+				return m, tea.Quit
+			}
+			m.breaking = m.cursor == 0
+			if !m.breaking {
+				return m, tea.Quit
+			}
+			m.describing = true
+			return m, m.description.Focus()
+		}
+
+		if !m.describing {
+			switch msg.String() {
+			case "q":
+				m.quit = true
+				return m, tea.Quit
+			case "up", "k":
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case "down", "j":
+				if m.cursor < len(m.choices)-1 {
+					m.cursor++
+				}
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.describing {
+		m.description, cmd = m.description.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *BreakingConfirm) View() string {
+	s := "\n"
+	if m.suspected {
+		s += TitleStyle.Render("💥 Your therapist thinks these changes break things for your users.") + "\n"
+	} else {
+		s += TitleStyle.Render("💥 Some of these changes look like they could break things for your users.") + "\n"
+	}
+	for _, finding := range m.findings {
+		s += HelpStyle.Render("  - "+finding) + "\n"
+	}
+
+	if m.describing {
+		s += "\n" + TitleStyle.Render("🧐 Describe the breaking change (leave empty for just the \"!\"):") + "\n"
+		s += m.description.View() + "\n"
+		return WrapWithKeyboardHelp(s, WithProceed())
+	}
+
+	s += "\n" + TitleStyle.Render("🧐 Is this a breaking change?") + "\n"
+	for i, choice := range m.choices {
+		cursor := " "
+		style := ItemStyle
+
+		if i == m.cursor {
+			cursor = ">"
+			style = SelectedItemStyle
+		}
+
+		s += fmt.Sprintf("%s %s\n", cursor, style.Render(choice))
+	}
+
+	return WrapWithKeyboardHelp(s, WithStandardNavigation())
+}
+
+// ConfirmBreakingChange asks whether the changes are breaking and, if so, for
+// a description to put in the BREAKING CHANGE footer.
+func ConfirmBreakingChange(findings []string, suspected bool, description string) (bool, string, error) {
+	model := NewBreakingConfirm(findings, suspected, description)
+	p := tea.NewProgram(model)
+
+	_, err := p.Run()
+	if err != nil {
+		return false, "", err
+	}
+
+	if model.quit {
+		return false, "", QuitError{}
+	}
+
+	return model.breaking, model.description.Value(), nil
+}
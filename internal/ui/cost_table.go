@@ -2,92 +2,335 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cowboy-bebug/kommit/internal/utils"
 )
 
+const (
+	sparklineDays    = 30
+	maxCostTableRows = 15
+	costColumnKey    = 0
+	costColumnCalls  = 1
+	costColumnTokens = 2
+	costColumnCost   = 3
+	costColumnCount  = 4
+)
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+var costGroupTitles = map[utils.CostGroupBy]string{
+	utils.CostGroupByRepo:    "Repository",
+	utils.CostGroupByModel:   "Model",
+	utils.CostGroupByMonth:   "Month",
+	utils.CostGroupByDay:     "Day",
+	utils.CostGroupByCommand: "Command",
+}
+
+var costTabTitles = map[utils.CostGroupBy]string{
+	utils.CostGroupByRepo:    "Repositories",
+	utils.CostGroupByModel:   "Models",
+	utils.CostGroupByMonth:   "Months",
+	utils.CostGroupByDay:     "Days",
+	utils.CostGroupByCommand: "Commands",
+}
+
+type costAction string
+
+const (
+	costActionNone    costAction = ""
+	costActionReset   costAction = "reset"
+	costActionArchive costAction = "archive"
+)
+
 type Model struct {
-	table table.Model
-	quit  bool
+	entries    []utils.CostEntry
+	filter     utils.CostFilter
+	repoID     utils.RepoName
+	tab        int
+	sortColumn int
+	descending bool
+	table      table.Model
+	confirm    costAction
+	target     string
+	status     string
+	quit       bool
 }
 
 func (m Model) Init() tea.Cmd {
 	return nil
 }
 
+func (m Model) by() utils.CostGroupBy {
+	return utils.CostGroupByOptions[m.tab]
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.confirm != costActionNone {
+			switch msg.String() {
+			case "y", "Y":
+				m = m.apply()
+			default:
+				m.status = "🧐 Your bills are safe. Nothing was changed."
+			}
+			m.confirm = costActionNone
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.quit = true
 			return m, tea.Quit
+		case "left", "h", "shift+tab":
+			m.tab = (m.tab + len(utils.CostGroupByOptions) - 1) % len(utils.CostGroupByOptions)
+			m.status = ""
+			return m.refresh(), nil
+		case "right", "l", "tab":
+			m.tab = (m.tab + 1) % len(utils.CostGroupByOptions)
+			m.status = ""
+			return m.refresh(), nil
+		case "s":
+			m.sortColumn = (m.sortColumn + 1) % costColumnCount
+			return m.refresh(), nil
+		case "o":
+			m.descending = !m.descending
+			return m.refresh(), nil
+		case "d", "x":
+			row := m.table.SelectedRow()
+			if m.by() != utils.CostGroupByRepo || row == nil || m.table.Cursor() == len(m.table.Rows())-1 {
+				return m, nil
+			}
+			m.confirm = costActionReset
+			if msg.String() == "x" {
+				m.confirm = costActionArchive
+			}
+			m.target = row[costColumnKey]
+			return m, nil
 		}
 	}
 	m.table, cmd = m.table.Update(msg)
 	return m, cmd
 }
 
-func (m Model) View() string {
-	s := TitleStyle.Render("💰 Kommit Financial Therapy Session 💰") +
-		"\n\n" + m.table.View() + "\n"
-	return WrapWithKeyboardHelp(s, WithBasicNavigation())
+// apply performs the confirmed reset or archive and reloads the ledger.
+func (m Model) apply() Model {
+	removed, err := utils.RemoveCosts(utils.RepoName(m.target), m.filter, m.confirm == costActionArchive)
+	if err != nil {
+		m.status = fmt.Sprintf("😰 Couldn't %s %s: %v", m.confirm, m.target, err)
+		return m
+	}
+
+	entries, err := utils.GetCosts(m.filter)
+	if err != nil {
+		entries = nil
+	}
+	m.entries = entries
+
+	verb := "Reset"
+	if m.confirm == costActionArchive {
+		verb = "Archived"
+	}
+	m.status = fmt.Sprintf("🧹 %s %d session(s) of %s.", verb, removed, m.target)
+	return m.refresh()
+}
+
+func (m Model) sortedGroups() []utils.CostGroup {
+	groups := utils.GroupCosts(m.entries, m.by())
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if m.descending {
+			a, b = b, a
+		}
+		switch m.sortColumn {
+		case costColumnCalls:
+			return a.Calls < b.Calls
+		case costColumnTokens:
+			return a.Tokens() < b.Tokens()
+		case costColumnCost:
+			return a.Cost < b.Cost
+		default:
+			return a.Key < b.Key
+		}
+	})
+	return groups
 }
 
-func NewTableModel(costs utils.Costs) Model {
+func (m Model) columns() []table.Column {
 	columns := []table.Column{
-		{Title: "Repository", Width: 45},
+		{Title: costGroupTitles[m.by()], Width: 45},
+		{Title: "Calls", Width: 7},
+		{Title: "Tokens", Width: 10},
 		{Title: "Cost ($)", Width: 15},
 	}
 
+	arrow := " ↑"
+	if m.descending {
+		arrow = " ↓"
+	}
+	columns[m.sortColumn].Title += arrow
+	return columns
+}
+
+// refresh rebuilds the table for the current tab and sort order, placing the
+// cursor on the current repository when it is listed.
+func (m Model) refresh() Model {
+	groups := m.sortedGroups()
+
 	rows := []table.Row{}
-	var totalCost float64
-
-	thisRepoIndex := 0
-	repoName, _ := utils.GetRepoName()
-	for repo, cost := range costs {
-		rows = append(rows, table.Row{string(repo), fmt.Sprintf("%.5f", cost)})
-		totalCost += float64(cost)
-
-		if string(repo) == repoName {
-			thisRepoIndex = len(rows) - 1
-		} else {
-			thisRepoIndex++
+	cursor := 0
+	for i, group := range groups {
+		rows = append(rows, costRow(group))
+		if m.by() == utils.CostGroupByRepo && group.Key == string(m.repoID) {
+			cursor = i
 		}
 	}
-	rows = append(rows, table.Row{"TOTAL", fmt.Sprintf("%.5f", totalCost)})
+	rows = append(rows, costRow(utils.TotalCosts(groups)))
+
+	m.table.SetColumns(m.columns())
+	m.table.SetRows(rows)
+	m.table.SetHeight(min(len(rows), maxCostTableRows) + 1)
+	m.table.SetCursor(cursor)
+	return m
+}
 
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(len(rows)+1),
+func (m Model) tabsView() string {
+	tabs := make([]string, len(utils.CostGroupByOptions))
+	for i, by := range utils.CostGroupByOptions {
+		style := ItemStyle
+		if i == m.tab {
+			style = SelectedItemStyle
+		}
+		tabs[i] = style.Render(costTabTitles[by])
+	}
+	return strings.Join(tabs, HelpStyle.Render("│"))
+}
+
+func sparkline(values []utils.Cost) (string, utils.Cost) {
+	var peak utils.Cost
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = int(float64(v/peak) * float64(len(sparklineLevels)-1))
+		}
+		b.WriteRune(sparklineLevels[level])
+	}
+	return b.String(), peak
+}
+
+func (m Model) sparklineView() string {
+	end := time.Now()
+	if !m.filter.Until.IsZero() && m.filter.Until.Before(end) {
+		end = m.filter.Until.Add(-time.Nanosecond)
+	}
+
+	line, peak := sparkline(utils.DailyCosts(m.entries, sparklineDays, end))
+	return fmt.Sprintf("%s %s %s",
+		HelpStyle.Render(fmt.Sprintf("Daily spend, last %d days:", sparklineDays)),
+		CheckedStyle.Render(line),
+		HelpStyle.Render(fmt.Sprintf("(peak $%.5f)", peak)),
 	)
-	t.SetCursor(thisRepoIndex)
+}
+
+func (m Model) View() string {
+	s := TitleStyle.Render("💰 Kommit Financial Therapy Session 💰") + "\n\n" +
+		m.tabsView() + "\n\n" +
+		m.sparklineView() + "\n\n" +
+		m.table.View() + "\n"
 
+	switch {
+	case m.confirm != costActionNone:
+		sessions := "all sessions"
+		if !m.filter.IsZero() {
+			sessions = "the sessions shown"
+		}
+		s += fmt.Sprintf("\n%s %s of %s? %s",
+			strings.ToUpper(string(m.confirm[:1]))+string(m.confirm[1:]),
+			sessions,
+			m.target,
+			KeyStyle.Render("(y/N)"))
+	case m.status != "":
+		s += "\n" + m.status
+	}
+
+	options := []HelpOption{WithBasicNavigation(), WithTabs(), WithSort()}
+	if m.by() == utils.CostGroupByRepo {
+		options = append(options, WithResetOptions())
+	}
+	return WrapWithKeyboardHelp(s, options...)
+}
+
+func costRow(group utils.CostGroup) table.Row {
+	return table.Row{
+		group.Key,
+		fmt.Sprintf("%d", group.Calls),
+		fmt.Sprintf("%d", group.Tokens()),
+		fmt.Sprintf("%.5f", group.Cost),
+	}
+}
+
+func NewTableModel(entries []utils.CostEntry, filter utils.CostFilter, by utils.CostGroupBy) Model {
+	tab := 0
+	for i, option := range utils.CostGroupByOptions {
+		if option == by {
+			tab = i
+		}
+	}
+
+	t := table.New(table.WithFocused(true))
 	s := table.DefaultStyles()
 	s.Header = TableHeaderStyle
 	s.Selected = SelectedItemStyle.Padding(0, 0)
 	t.SetStyles(s)
 
-	return Model{table: t, quit: false}
+	m := Model{
+		entries: entries,
+		filter:  filter,
+		repoID:  utils.GetRepoID(),
+		tab:     tab,
+		table:   t,
+		quit:    false,
+	}
+	return m.refresh()
 }
 
-func CostTableModel(costs utils.Costs) error {
-	model := NewTableModel(costs)
+func CostTableModel(entries []utils.CostEntry, filter utils.CostFilter, by utils.CostGroupBy) error {
+	model := NewTableModel(entries, filter, by)
 	p := tea.NewProgram(model)
 
-	_, err := p.Run()
+	final, err := p.Run()
 	if err != nil {
 		return err
 	}
 
-	if model.quit {
+	if final.(Model).quit {
 		return QuitError{}
 	}
 
 	return nil
 }
+
+// PrintCostTable writes the same table as CostTableModel without any styling
+// or interaction, for when stdout is not a terminal.
+func PrintCostTable(w io.Writer, groups []utils.CostGroup, by utils.CostGroupBy) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCalls\tTokens\tCost ($)\n", costGroupTitles[by])
+	for _, group := range append(groups, utils.TotalCosts(groups)) {
+		row := costRow(group)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", row[0], row[1], row[2], row[3])
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowboy-bebug/kommit/internal/patch"
+)
+
+const maxHunkRows = 15
+
+type HunkItem struct {
+	Unit     patch.Unit
+	Selected bool
+}
+
+type HunkSelector struct {
+	hunks  []HunkItem
+	cursor int
+	quit   bool
+}
+
+func NewHunkSelector(units []patch.Unit) *HunkSelector {
+	hunks := make([]HunkItem, len(units))
+	for i, unit := range units {
+		hunks[i] = HunkItem{Unit: unit, Selected: false}
+	}
+
+	return &HunkSelector{
+		hunks:  hunks,
+		cursor: 0,
+		quit:   false,
+	}
+}
+
+func (m *HunkSelector) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HunkSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			m.quit = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.hunks)-1 {
+				m.cursor++
+			}
+		case " ":
+			m.hunks[m.cursor].Selected = !m.hunks[m.cursor].Selected
+		case "enter", "return":
+			return m, tea.Quit
+		case "a":
+			for i := range m.hunks {
+				m.hunks[i].Selected = true
+			}
+		case "n":
+			for i := range m.hunks {
+				m.hunks[i].Selected = false
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *HunkSelector) View() string {
+	s := TitleStyle.Render("Select the changes you're ready to talk about:") + "\n"
+
+	// Keep the cursor in view when there are more hunks than rows
+	start := min(max(m.cursor-maxHunkRows/2, 0), max(len(m.hunks)-maxHunkRows, 0))
+	end := min(start+maxHunkRows, len(m.hunks))
+	if start > 0 {
+		s += HelpStyle.Render(fmt.Sprintf("  ↑ %d more", start)) + "\n"
+	}
+
+	for i := start; i < end; i++ {
+		item := m.hunks[i]
+
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+
+		checked := "[ ]"
+		if item.Selected {
+			checked = "[x]"
+		}
+
+		checkboxStyle := UncheckedStyle
+		nameStyle := ItemStyle
+
+		if item.Selected {
+			checkboxStyle = CheckedStyle
+		}
+
+		if i == m.cursor {
+			nameStyle = SelectedItemStyle
+		}
+
+		s += cursor + " " + checkboxStyle.Render(checked) + " " + nameStyle.Render(item.Unit.String()) + "\n"
+	}
+
+	if end < len(m.hunks) {
+		s += HelpStyle.Render(fmt.Sprintf("  ↓ %d more", len(m.hunks)-end)) + "\n"
+	}
+
+	if len(m.hunks) > 0 {
+		s += "\n" + diffPreview(m.hunks[m.cursor].Unit) + "\n"
+	}
+
+	return WrapWithKeyboardHelp(s,
+		WithStandardNavigation(),
+		WithSelectionOptions(),
+	)
+}
+
+func (m *HunkSelector) GetSelectedUnits() []patch.Unit {
+	var selected []patch.Unit
+	for _, item := range m.hunks {
+		if item.Selected {
+			selected = append(selected, item.Unit)
+		}
+	}
+	return selected
+}
+
+// SelectHunks lets the user pick which hunks to stage.
+func SelectHunks(units []patch.Unit) ([]patch.Unit, error) {
+	model := NewHunkSelector(units)
+	p := tea.NewProgram(model)
+
+	_, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	if model.quit {
+		return nil, QuitError{}
+	}
+
+	return model.GetSelectedUnits(), nil
+}
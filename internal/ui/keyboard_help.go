@@ -12,6 +12,15 @@ var (
 	ToggleKey    = KeyStyle.Render("Space")
 	SelectKey    = KeyStyle.Render("a")
 	DeselectKey  = KeyStyle.Render("n")
+	TabKey1      = KeyStyle.Render("←/→")
+	TabKey2      = KeyStyle.Render("h/l")
+	SortKey      = KeyStyle.Render("s")
+	ReverseKey   = KeyStyle.Render("o")
+	ResetKey     = KeyStyle.Render("d")
+	ArchiveKey   = KeyStyle.Render("x")
+	MoveKey1     = KeyStyle.Render("←/→")
+	MoveKey2     = KeyStyle.Render("h/l")
+	NewGroupKey  = KeyStyle.Render("g")
 )
 
 // words
@@ -25,6 +34,13 @@ var (
 	ToToggle   = HelpStyle.Render("to toggle")
 	ToSelect   = HelpStyle.Render("to select")
 	ToDeselect = HelpStyle.Render("to deselect")
+	ToSwitch   = HelpStyle.Render("to switch tabs")
+	ToSort     = HelpStyle.Render("to sort by the next column")
+	ToReverse  = HelpStyle.Render("to reverse the order")
+	ToReset    = HelpStyle.Render("to reset the selected repository")
+	ToArchive  = HelpStyle.Render("to archive the selected repository")
+	ToMove     = HelpStyle.Render("to move the hunk to another commit")
+	ToNewGroup = HelpStyle.Render("to move the hunk to a new commit")
 )
 
 // help messages
@@ -35,6 +51,13 @@ var (
 	Toggle   = fmt.Sprintf("  %s %s %s\n", Press, ToggleKey, ToToggle)
 	Select   = fmt.Sprintf("  %s %s %s\n", Press, SelectKey, ToSelect)
 	Deselect = fmt.Sprintf("  %s %s %s\n", Press, DeselectKey, ToDeselect)
+	Tabs     = fmt.Sprintf("  %s %s %s %s %s\n", Use, TabKey1, Or, TabKey2, ToSwitch)
+	Sort     = fmt.Sprintf("  %s %s %s\n", Press, SortKey, ToSort)
+	Reverse  = fmt.Sprintf("  %s %s %s\n", Press, ReverseKey, ToReverse)
+	Reset    = fmt.Sprintf("  %s %s %s\n", Press, ResetKey, ToReset)
+	Archive  = fmt.Sprintf("  %s %s %s\n", Press, ArchiveKey, ToArchive)
+	Move     = fmt.Sprintf("  %s %s %s %s %s\n", Use, MoveKey1, Or, MoveKey2, ToMove)
+	NewGroup = fmt.Sprintf("  %s %s %s\n", Press, NewGroupKey, ToNewGroup)
 )
 
 type HelpOption func(*helpConfig)
@@ -45,6 +68,12 @@ type helpConfig struct {
 	showToggle   bool
 	showSelect   bool
 	showDeselect bool
+	showTabs     bool
+	showSort     bool
+	showReset    bool
+	showArchive  bool
+	showMove     bool
+	showNewGroup bool
 }
 
 func WithNavigation() HelpOption {
@@ -83,6 +112,32 @@ func WithDeselect() HelpOption {
 	}
 }
 
+func WithTabs() HelpOption {
+	return func(c *helpConfig) {
+		c.showTabs = true
+	}
+}
+
+func WithSort() HelpOption {
+	return func(c *helpConfig) {
+		c.showSort = true
+	}
+}
+
+func WithResetOptions() HelpOption {
+	return func(c *helpConfig) {
+		c.showReset = true
+		c.showArchive = true
+	}
+}
+
+func WithGroupOptions() HelpOption {
+	return func(c *helpConfig) {
+		c.showMove = true
+		c.showNewGroup = true
+	}
+}
+
 func WithSelectionOptions() HelpOption {
 	return func(c *helpConfig) {
 		c.showToggle = true
@@ -145,5 +200,30 @@ func WrapWithKeyboardHelp(s string, options ...HelpOption) string {
 		s += Deselect
 	}
 
+	if config.showTabs {
+		s += Tabs
+	}
+
+	if config.showSort {
+		s += Sort
+		s += Reverse
+	}
+
+	if config.showReset {
+		s += Reset
+	}
+
+	if config.showArchive {
+		s += Archive
+	}
+
+	if config.showMove {
+		s += Move
+	}
+
+	if config.showNewGroup {
+		s += NewGroup
+	}
+
 	return s
 }
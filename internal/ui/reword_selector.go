@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type RewordAction string
+
+const (
+	RewordActionAccept RewordAction = "accept"
+	RewordActionEdit   RewordAction = "edit"
+	RewordActionKeep   RewordAction = "keep"
+)
+
+var rewordActions = []RewordAction{
+	RewordActionAccept,
+	RewordActionEdit,
+	RewordActionKeep,
+}
+
+type RewordItem struct {
+	Hash     string
+	Subject  string
+	Proposed string
+	Action   RewordAction
+}
+
+type RewordSelector struct {
+	items  []RewordItem
+	cursor int
+	quit   bool
+}
+
+func NewRewordSelector(items []RewordItem) *RewordSelector {
+	return &RewordSelector{
+		items:  items,
+		cursor: 0,
+		quit:   false,
+	}
+}
+
+func (m *RewordSelector) Init() tea.Cmd {
+	return nil
+}
+
+func (m *RewordSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			m.quit = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+		case " ":
+			item := &m.items[m.cursor]
+			for i, action := range rewordActions {
+				if action == item.Action {
+					item.Action = rewordActions[(i+1)%len(rewordActions)]
+					break
+				}
+			}
+		case "a":
+			for i := range m.items {
+				m.items[i].Action = RewordActionAccept
+			}
+		case "n":
+			for i := range m.items {
+				m.items[i].Action = RewordActionKeep
+			}
+		case "enter":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m *RewordSelector) View() string {
+	s := TitleStyle.Render("🧐 Review your therapist's suggestions for each commit:") + "\n"
+
+	for i, item := range m.items {
+		cursor := " "
+		style := ItemStyle
+		if i == m.cursor {
+			cursor = ">"
+			style = SelectedItemStyle
+		}
+
+		actionStyle := CheckedStyle
+		subject := item.Proposed
+		if item.Action == RewordActionKeep {
+			actionStyle = UncheckedStyle
+			subject = item.Subject
+		}
+		subject, _, _ = strings.Cut(subject, "\n")
+
+		s += fmt.Sprintf("%s %s %s %s\n",
+			cursor,
+			actionStyle.Render(fmt.Sprintf("[%-6s]", item.Action)),
+			HelpStyle.Render(item.Hash),
+			style.Render(subject),
+		)
+	}
+
+	if len(m.items) > 0 {
+		item := m.items[m.cursor]
+		s += "\n" + HelpStyle.Render("Was: "+item.Subject) + "\n"
+		s += HelpStyle.Render("Proposed:") + "\n" + CheckedStyle.Render(item.Proposed) + "\n"
+	}
+
+	return WrapWithKeyboardHelp(s,
+		WithStandardNavigation(),
+		WithToggle(),
+		WithSelect(),
+		WithDeselect(),
+	)
+}
+
+// SelectRewordActions lets the user accept, edit or keep each proposed
+// message. Items are returned with the chosen actions.
+func SelectRewordActions(items []RewordItem) ([]RewordItem, error) {
+	model := NewRewordSelector(items)
+	p := tea.NewProgram(model)
+
+	_, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	if model.quit {
+		return nil, QuitError{}
+	}
+
+	return model.items, nil
+}
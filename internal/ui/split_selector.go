@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowboy-bebug/kommit/internal/patch"
+)
+
+const maxDiffPreviewLines = 15
+
+type splitItem struct {
+	unit  patch.Unit
+	group int
+}
+
+type SplitSelector struct {
+	titles []string
+	// items stay in diff order; only their group changes
+	items  []splitItem
+	cursor int
+	quit   bool
+}
+
+func NewSplitSelector(groups []patch.Group) *SplitSelector {
+	m := &SplitSelector{}
+	for g, group := range groups {
+		m.titles = append(m.titles, group.Title)
+		for _, unit := range group.Units {
+			m.items = append(m.items, splitItem{unit: unit, group: g})
+		}
+	}
+	if order := m.order(); len(order) > 0 {
+		m.cursor = order[0]
+	}
+	return m
+}
+
+// order lists item indexes as displayed: group by group, in diff order.
+func (m *SplitSelector) order() []int {
+	var order []int
+	for g := range m.titles {
+		for i, item := range m.items {
+			if item.group == g {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
+
+func (m *SplitSelector) moveCursor(delta int) {
+	order := m.order()
+	for pos, i := range order {
+		if i == m.cursor {
+			pos = min(max(pos+delta, 0), len(order)-1)
+			m.cursor = order[pos]
+			return
+		}
+	}
+}
+
+func (m *SplitSelector) Init() tea.Cmd {
+	return nil
+}
+
+func (m *SplitSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if len(m.items) == 0 {
+			break
+		}
+		item := &m.items[m.cursor]
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			m.quit = true
+			return m, tea.Quit
+		case "up", "k":
+			m.moveCursor(-1)
+		case "down", "j":
+			m.moveCursor(1)
+		case "left", "h":
+			if item.group > 0 {
+				item.group--
+			}
+		case "right", "l":
+			if item.group < len(m.titles)-1 {
+				item.group++
+			}
+		case "g":
+			m.titles = append(m.titles, fmt.Sprintf("Commit %d", len(m.titles)+1))
+			item.group = len(m.titles) - 1
+		case "enter":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m *SplitSelector) View() string {
+	s := TitleStyle.Render("🧐 Your therapist suggests splitting these changes into separate commits:") + "\n"
+
+	for g, title := range m.titles {
+		s += "\n" + CheckedStyle.Render(fmt.Sprintf("%d. %s", g+1, title)) + "\n"
+
+		empty := true
+		for i, item := range m.items {
+			if item.group != g {
+				continue
+			}
+			empty = false
+
+			cursor := " "
+			style := ItemStyle
+			if i == m.cursor {
+				cursor = ">"
+				style = SelectedItemStyle
+			}
+			s += fmt.Sprintf("%s %s\n", cursor, style.Render(item.unit.String()))
+		}
+		if empty {
+			s += "  " + HelpStyle.Render("(empty, will be dropped)") + "\n"
+		}
+	}
+
+	if len(m.items) > 0 {
+		s += "\n" + diffPreview(m.items[m.cursor].unit) + "\n"
+	}
+
+	return WrapWithKeyboardHelp(s,
+		WithStandardNavigation(),
+		WithGroupOptions(),
+	)
+}
+
+// diffPreview renders the start of a unit's diff below a list.
+func diffPreview(unit patch.Unit) string {
+	lines := strings.Split(strings.TrimSuffix(unit.Diff(), "\n"), "\n")
+	if len(lines) > maxDiffPreviewLines {
+		lines = append(lines[:maxDiffPreviewLines], "...")
+	}
+	return HelpStyle.Render(strings.Join(lines, "\n"))
+}
+
+// Groups returns the adjusted groups, dropping any left empty.
+func (m *SplitSelector) Groups() []patch.Group {
+	var groups []patch.Group
+	for g, title := range m.titles {
+		group := patch.Group{Title: title}
+		for _, item := range m.items {
+			if item.group == g {
+				group.Units = append(group.Units, item.unit)
+			}
+		}
+		if len(group.Units) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// SelectSplitGroups lets the user move hunks between the proposed commits.
+func SelectSplitGroups(groups []patch.Group) ([]patch.Group, error) {
+	model := NewSplitSelector(groups)
+	p := tea.NewProgram(model)
+
+	_, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	if model.quit {
+		return nil, QuitError{}
+	}
+
+	return model.Groups(), nil
+}
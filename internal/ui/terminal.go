@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// IsTerminal reports whether both stdin and stdout are attached to a terminal,
+// i.e. whether it is safe to start an interactive Bubble Tea program.
+func IsTerminal() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// IsStdinTerminal reports whether stdin is attached to a terminal, i.e.
+// whether reading it would wait for the user to type rather than for a pipe.
+func IsStdinTerminal() bool {
+	return isTerminal(os.Stdin)
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
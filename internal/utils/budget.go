@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+const defaultBudgetWarnAt = 80.0
+
+type BudgetScope string
+
+const (
+	BudgetScopeGlobal BudgetScope = "global"
+	BudgetScopeRepo   BudgetScope = "repository"
+)
+
+type BudgetUsage struct {
+	Scope  BudgetScope
+	Spent  Cost
+	Limit  Cost
+	WarnAt float64
+}
+
+func (u BudgetUsage) Percent() float64 {
+	if u.Limit <= 0 {
+		return 0
+	}
+	return float64(u.Spent/u.Limit) * 100
+}
+
+func (u BudgetUsage) Exceeded() bool {
+	return u.Spent >= u.Limit
+}
+
+func (u BudgetUsage) Warn() bool {
+	return u.Percent() >= u.WarnAt
+}
+
+type BudgetReport struct {
+	Usages []BudgetUsage
+}
+
+func (r BudgetReport) Exceeded() []BudgetUsage {
+	var exceeded []BudgetUsage
+	for _, u := range r.Usages {
+		if u.Exceeded() {
+			exceeded = append(exceeded, u)
+		}
+	}
+	return exceeded
+}
+
+func (r BudgetReport) Warnings() []BudgetUsage {
+	var warnings []BudgetUsage
+	for _, u := range r.Usages {
+		if !u.Exceeded() && u.Warn() {
+			warnings = append(warnings, u)
+		}
+	}
+	return warnings
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func warnAtOrDefault(warnAt float64) float64 {
+	if warnAt <= 0 {
+		return defaultBudgetWarnAt
+	}
+	return warnAt
+}
+
+// CheckBudget compares this month's recorded spend against the global monthly
+// budget from the user config and the repository budget, if any are set.
+func CheckBudget(repoBudget BudgetConfig) (BudgetReport, error) {
+	userConfig, err := LoadUserConfig()
+	if err != nil {
+		return BudgetReport{}, err
+	}
+
+	var report BudgetReport
+	if userConfig.Budget.Monthly <= 0 && repoBudget.Monthly <= 0 {
+		return report, nil
+	}
+
+	entries, err := GetCosts(CostFilter{Since: startOfMonth(time.Now())})
+	if err != nil && !errors.Is(err, CostFileNotFoundError{}) {
+		return BudgetReport{}, err
+	}
+
+	repoID := GetRepoID()
+	var globalSpent, repoSpent Cost
+	for _, entry := range entries {
+		globalSpent += entry.Cost
+		if entry.Repo == repoID {
+			repoSpent += entry.Cost
+		}
+	}
+
+	if userConfig.Budget.Monthly > 0 {
+		report.Usages = append(report.Usages, BudgetUsage{
+			Scope:  BudgetScopeGlobal,
+			Spent:  globalSpent,
+			Limit:  Cost(userConfig.Budget.Monthly),
+			WarnAt: warnAtOrDefault(userConfig.Budget.WarnAt),
+		})
+	}
+
+	if repoBudget.Monthly > 0 {
+		report.Usages = append(report.Usages, BudgetUsage{
+			Scope:  BudgetScopeRepo,
+			Spent:  repoSpent,
+			Limit:  Cost(repoBudget.Monthly),
+			WarnAt: warnAtOrDefault(repoBudget.WarnAt),
+		})
+	}
+
+	return report, nil
+}
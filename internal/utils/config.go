@@ -11,7 +11,10 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-const configFilename = ".kommitrc.yaml"
+const (
+	configFilename     = ".kommitrc.yaml"
+	userConfigFilename = "config.yaml"
+)
 
 func GetConfigPath() (string, error) {
 	output, err := ExecGit("rev-parse", "--show-toplevel")
@@ -42,13 +45,134 @@ type LLMConfig struct {
 }
 
 type CommitConfig struct {
-	Types  []string `mapstructure:"types"`
-	Scopes []string `mapstructure:"scopes"`
+	Types     []string        `mapstructure:"types"`
+	Scopes    []string        `mapstructure:"scopes"`
+	Signature SignatureConfig `mapstructure:"signature"`
+	// Trailers are added to every generated message, e.g. "Reviewed-by: "
+	Trailers   []string         `mapstructure:"trailers"`
+	References ReferencesConfig `mapstructure:"references"`
+	Format     FormatConfig     `mapstructure:"format"`
+	Changelog  ChangelogConfig  `mapstructure:"changelog"`
+}
+
+type ChangelogConfig struct {
+	// Sections map commit types to changelog sections, in order. Types
+	// that aren't listed are left out.
+	Sections      []ChangelogSection `mapstructure:"sections"`
+	BreakingTitle string             `mapstructure:"breaking_title"`
+	// File is CHANGELOG.md by default
+	File string `mapstructure:"file"`
+	// URL of the repo for links, derived from the origin remote by default
+	URL string `mapstructure:"url"`
+	// Polish has the therapist rewrite subjects as release notes
+	Polish bool `mapstructure:"polish"`
+}
+
+type ChangelogSection struct {
+	Type  string `mapstructure:"type"`
+	Title string `mapstructure:"title"`
+}
+
+type FormatConfig struct {
+	// Transformers run in this order; leave empty for the default pipeline
+	Transformers []string `mapstructure:"transformers"`
+	// Disable turns transformers off without listing all the others
+	Disable []string `mapstructure:"disable"`
+	// BodyWidth and SubjectMaxLength default to 72
+	BodyWidth        int `mapstructure:"body_width"`
+	SubjectMaxLength int `mapstructure:"subject_max_length"`
+	// Bullet is the bullet character, "-" by default
+	Bullet string `mapstructure:"bullet"`
+}
+
+type ReferencesConfig struct {
+	// Patterns are preset names (jira, github, linear) or regular
+	// expressions matched against the branch name
+	Patterns []string `mapstructure:"patterns"`
+	// Placement is footer (the default) or subject
+	Placement string `mapstructure:"placement"`
+	// Token is the footer token, "Refs" by default
+	Token string `mapstructure:"token"`
+}
+
+type SignatureConfig struct {
+	// Style is one of text (the default), trailer or off
+	Style   string `mapstructure:"style"`
+	Text    string `mapstructure:"text"`
+	Trailer string `mapstructure:"trailer"`
+}
+
+type BudgetConfig struct {
+	Monthly float64 `mapstructure:"monthly"`
+	WarnAt  float64 `mapstructure:"warn_at"`
+}
+
+type VersionConfig struct {
+	// TagPrefix is what release tags start with, "v" or nothing by default
+	TagPrefix string `mapstructure:"tag_prefix"`
+	// Initial is the first version when there are no tags, 0.1.0 by default
+	Initial string `mapstructure:"initial"`
+	// Prerelease, e.g. "rc", makes every next version a pre-release
+	Prerelease string `mapstructure:"prerelease"`
+	// Before 1.0.0, breaking changes bump minor (the default) or major
+	PreMajorBreaking string `mapstructure:"pre_major_breaking"`
+	// Before 1.0.0, features bump minor (the default) or patch
+	PreMajorFeatures string `mapstructure:"pre_major_features"`
+	// MinorTypes default to feat, PatchTypes to fix and perf
+	MinorTypes []string `mapstructure:"minor_types"`
+	PatchTypes []string `mapstructure:"patch_types"`
 }
 
 type Config struct {
-	LLM    LLMConfig    `mapstructure:"llm"`
-	Commit CommitConfig `mapstructure:"commit"`
+	LLM     LLMConfig     `mapstructure:"llm"`
+	Commit  CommitConfig  `mapstructure:"commit"`
+	Budget  BudgetConfig  `mapstructure:"budget"`
+	Version VersionConfig `mapstructure:"version"`
+}
+
+// UserConfig holds settings that apply to every repository, read from
+// $XDG_CONFIG_HOME/kommit/config.yaml.
+type UserConfig struct {
+	Budget BudgetConfig `mapstructure:"budget"`
+}
+
+func GetUserConfigFilePath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "kommit", userConfigFilename), nil
+}
+
+// LoadUserConfig reads the global user config. A missing file yields an empty
+// config rather than an error, since every setting in it is optional.
+func LoadUserConfig() (*UserConfig, error) {
+	userConfigFilePath, err := GetUserConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(userConfigFilePath); os.IsNotExist(err) {
+		return &UserConfig{}, nil
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetConfigFile(userConfigFilePath)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var userConfig UserConfig
+	if err := v.Unmarshal(&userConfig); err != nil {
+		return nil, fmt.Errorf("error unmarshaling user config: %w", err)
+	}
+	return &userConfig, nil
 }
 
 func LoadConfig() (*Config, error) {
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type CostFormat string
+
+const (
+	CostFormatTable CostFormat = "table"
+	CostFormatJSON  CostFormat = "json"
+	CostFormatCSV   CostFormat = "csv"
+)
+
+var CostFormatOptions = []CostFormat{
+	CostFormatTable,
+	CostFormatJSON,
+	CostFormatCSV,
+}
+
+func ParseCostFormat(s string) (CostFormat, error) {
+	for _, format := range CostFormatOptions {
+		if string(format) == s {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q", s)
+}
+
+type costGroupJSON struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func newCostGroupJSON(group CostGroup) costGroupJSON {
+	return costGroupJSON{
+		Key:              group.Key,
+		Calls:            group.Calls,
+		PromptTokens:     group.PromptTokens,
+		CachedTokens:     group.CachedTokens,
+		CompletionTokens: group.CompletionTokens,
+		Cost:             float64(group.Cost),
+	}
+}
+
+type costReportJSON struct {
+	GroupBy CostGroupBy     `json:"group_by"`
+	Groups  []costGroupJSON `json:"groups"`
+	Total   costGroupJSON   `json:"total"`
+}
+
+func WriteCostsJSON(w io.Writer, groups []CostGroup, by CostGroupBy) error {
+	report := costReportJSON{
+		GroupBy: by,
+		Groups:  make([]costGroupJSON, 0, len(groups)),
+		Total:   newCostGroupJSON(TotalCosts(groups)),
+	}
+	for _, group := range groups {
+		report.Groups = append(report.Groups, newCostGroupJSON(group))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCostsCSV writes one row per group. The total is left out so that the
+// output can be summed or pivoted without double counting.
+func WriteCostsCSV(w io.Writer, groups []CostGroup, by CostGroupBy) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{string(by), "calls", "prompt_tokens", "cached_tokens", "completion_tokens", "cost"})
+	for _, group := range groups {
+		writer.Write([]string{
+			group.Key,
+			strconv.Itoa(group.Calls),
+			strconv.FormatInt(group.PromptTokens, 10),
+			strconv.FormatInt(group.CachedTokens, 10),
+			strconv.FormatInt(group.CompletionTokens, 10),
+			strconv.FormatFloat(float64(group.Cost), 'f', -1, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
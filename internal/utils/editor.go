@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// EditMessage opens the message in the user's git editor (GIT_EDITOR,
+// core.editor, VISUAL or EDITOR) and returns the edited text.
+func EditMessage(message string) (string, error) {
+	editor, err := ExecGit("var", "GIT_EDITOR")
+	if err != nil {
+		return "", fmt.Errorf("failed to find an editor: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp("", ".kommit-edit-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFilePath := tempFile.Name()
+	defer os.Remove(tempFilePath)
+
+	if _, err := tempFile.WriteString(message + "\n"); err != nil {
+		tempFile.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tempFile.Close()
+
+	// Like git, let the shell split the editor command so it may carry flags
+	cmd := exec.Command("sh", "-c", strings.TrimSpace(editor)+` "$@"`, "editor", tempFilePath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(tempFilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	return string(edited), nil
+}
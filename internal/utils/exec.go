@@ -3,13 +3,25 @@ package utils
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 )
 
 func execCmd(name string, args ...string) (string, error) {
+	return execCmdWithInput("", nil, name, args...)
+}
+
+func execCmdWithInput(input string, env []string, name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	err := cmd.Run()
 	if err != nil {
@@ -22,3 +34,9 @@ func execCmd(name string, args ...string) (string, error) {
 func ExecGit(args ...string) (string, error) {
 	return execCmd("git", args...)
 }
+
+// ExecGitWithInput runs git with input on stdin and extra environment
+// variables (e.g. GIT_AUTHOR_DATE=...).
+func ExecGitWithInput(input string, env []string, args ...string) (string, error) {
+	return execCmdWithInput(input, env, "git", args...)
+}
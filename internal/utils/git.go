@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type CommitMessage struct {
+	Hash    string
+	Message string
+}
+
+// GetCommitMessages returns the full messages of the commits in a revision
+// range such as origin/main..HEAD, oldest first.
+func GetCommitMessages(revisionRange string) ([]CommitMessage, error) {
+	output, err := ExecGit("log", "--reverse", "--format=%H%x00%B%x1e", revisionRange, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commits in %s: %w", revisionRange, err)
+	}
+
+	var commits []CommitMessage
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		hash, message, ok := strings.Cut(record, "\x00")
+		if !ok {
+			continue
+		}
+		commits = append(commits, CommitMessage{
+			Hash:    hash,
+			Message: strings.TrimRight(message, "\n"),
+		})
+	}
+	return commits, nil
+}
+
+// EmptyTreeHash is the hash of git's empty tree, used as the parent of a root
+// commit when diffing.
+const EmptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// RevExists reports whether rev resolves to a commit.
+func RevExists(rev string) bool {
+	_, err := ExecGit("rev-parse", "--verify", "-q", rev+"^{commit}")
+	return err == nil
+}
+
+// ParentOrEmptyTree returns rev's first parent, or the empty tree for a root
+// commit, so that `git diff` against it shows all of rev's changes.
+func ParentOrEmptyTree(rev string) string {
+	if RevExists(rev + "^") {
+		return rev + "^"
+	}
+	return EmptyTreeHash
+}
+
+// GetRemoteBranchesContaining lists the remote-tracking branches that already
+// contain rev, i.e. where rewriting rev would rewrite published history.
+func GetRemoteBranchesContaining(rev string) ([]string, error) {
+	output, err := ExecGit("branch", "-r", "--format=%(refname:short)", "--contains", rev)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+var identityRegex = regexp.MustCompile(`^(.+?)\s*<([^<>\s]+)>$`)
+
+// GetCoAuthors returns "Name <email>" for everyone who worked on the commits
+// in a revision range, from both their authors and their Co-authored-by
+// trailers, in order of appearance. Each email appears once, and the one in
+// exclude (the author of the commit that credits them) not at all.
+func GetCoAuthors(revisionRange, exclude string) ([]string, error) {
+	output, err := ExecGit("log", "--reverse", "--format=%aN <%aE>%n%(trailers:key=Co-authored-by,valueonly)", revisionRange, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authors in %s: %w", revisionRange, err)
+	}
+
+	seen := map[string]bool{strings.ToLower(exclude): true}
+	var coAuthors []string
+	for _, line := range strings.Split(output, "\n") {
+		matches := identityRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		email := strings.ToLower(matches[2])
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		coAuthors = append(coAuthors, matches[1]+" <"+matches[2]+">")
+	}
+	return coAuthors, nil
+}
+
+// GetAuthorEmail returns the email git will use for the author of the next
+// commit.
+func GetAuthorEmail() string {
+	output, err := ExecGit("var", "GIT_AUTHOR_IDENT")
+	if err != nil {
+		return ""
+	}
+	ident, _, _ := strings.Cut(output, ">")
+	if _, email, ok := strings.Cut(ident, "<"); ok {
+		return email
+	}
+	return ""
+}
+
+// StageAll stages every modification and deletion of tracked files, like
+// `git commit -a`, and untracked files too if asked.
+func StageAll(includeUntracked bool) error {
+	args := []string{"add", "--update"}
+	if includeUntracked {
+		args = []string{"add", "--all"}
+	}
+	if _, err := ExecGit(args...); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	return nil
+}
+
+// GetPartiallyStagedFiles lists files with both staged and unstaged changes.
+func GetPartiallyStagedFiles() ([]string, error) {
+	staged, err := ExecGit("diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return nil, err
+	}
+	unstaged, err := ExecGit("diff", "--name-only", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	isUnstaged := make(map[string]bool)
+	for _, file := range strings.Split(unstaged, "\x00") {
+		isUnstaged[file] = file != ""
+	}
+
+	var files []string
+	for _, file := range strings.Split(staged, "\x00") {
+		if isUnstaged[file] {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// GetDefaultBase guesses the branch a feature branch will be merged into: the
+// default branch of the upstream or origin remote, or a local main or master.
+func GetDefaultBase() (string, error) {
+	for _, remote := range []string{"upstream", "origin"} {
+		ref, err := ExecGit("symbolic-ref", "-q", "--short", "refs/remotes/"+remote+"/HEAD")
+		if err == nil && strings.TrimSpace(ref) != "" {
+			return strings.TrimSpace(ref), nil
+		}
+	}
+	for _, branch := range []string{"main", "master", "origin/main", "origin/master"} {
+		if RevExists(branch) {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("no default branch found, pass one explicitly")
+}
+
+// GetUpstreamBase returns the upstream of the current branch when it tracks
+// another branch, as stacked branches do, or "" when it tracks its own copy
+// on a remote or nothing at all.
+func GetUpstreamBase() string {
+	branch := GetBranchName()
+	if branch == "" {
+		return ""
+	}
+	merge, err := ExecGit("config", "branch."+branch+".merge")
+	if err != nil || strings.TrimPrefix(strings.TrimSpace(merge), "refs/heads/") == branch {
+		return ""
+	}
+	upstream, err := ExecGit("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(upstream)
+}
+
+// GetCommentChar returns what starts comment lines in commit messages. With
+// core.commentChar set to auto, git picks a character per message, which
+// kommit's own hooks treat as the default "#".
+func GetCommentChar() string {
+	output, err := ExecGit("config", "core.commentChar")
+	if char := strings.TrimSpace(output); err == nil && char != "" && char != "auto" {
+		return char
+	}
+	return "#"
+}
+
+// GetMergeBase returns the commit where HEAD forked from base.
+func GetMergeBase(base string) (string, error) {
+	output, err := ExecGit("merge-base", base, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("no common ancestor of %s and HEAD: %w", base, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GetLatestTag returns the most recent tag reachable from rev.
+func GetLatestTag(rev string) (string, error) {
+	output, err := ExecGit("describe", "--tags", "--abbrev=0", rev)
+	if err != nil {
+		return "", fmt.Errorf("no tag reachable from %s: %w", rev, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GetExactTag returns a tag pointing at rev, or "" if there is none.
+func GetExactTag(rev string) string {
+	output, err := ExecGit("describe", "--tags", "--exact-match", rev)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// GetCommitDate returns rev's committer date as YYYY-MM-DD.
+func GetCommitDate(rev string) (string, error) {
+	output, err := ExecGit("log", "-1", "--format=%cs", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
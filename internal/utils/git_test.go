@@ -0,0 +1,50 @@
+package utils
+
+import "testing"
+
+func TestGetUpstreamBase(t *testing.T) {
+	newTestRepo(t)
+	commitFile(t, "root.txt", "root\n", "root")
+	mustGit(t, "branch", "develop")
+	mustGit(t, "checkout", "-q", "-b", "feature")
+
+	if got := GetUpstreamBase(); got != "" {
+		t.Errorf("GetUpstreamBase() without an upstream = %q, want none", got)
+	}
+
+	mustGit(t, "branch", "--set-upstream-to=develop")
+	if got := GetUpstreamBase(); got != "develop" {
+		t.Errorf("GetUpstreamBase() tracking develop = %q, want develop", got)
+	}
+
+	// A branch pushed to a remote tracks its own copy there, which is no base
+	mustGit(t, "remote", "add", "origin", ".")
+	mustGit(t, "update-ref", "refs/remotes/origin/feature", "HEAD")
+	mustGit(t, "config", "branch.feature.remote", "origin")
+	mustGit(t, "config", "branch.feature.merge", "refs/heads/feature")
+	if got := GetUpstreamBase(); got != "" {
+		t.Errorf("GetUpstreamBase() tracking origin/feature = %q, want none", got)
+	}
+
+	mustGit(t, "config", "branch.feature.merge", "refs/heads/develop")
+	mustGit(t, "update-ref", "refs/remotes/origin/develop", "HEAD")
+	if got := GetUpstreamBase(); got != "origin/develop" {
+		t.Errorf("GetUpstreamBase() tracking origin/develop = %q, want origin/develop", got)
+	}
+}
+
+func TestGetCommentChar(t *testing.T) {
+	newTestRepo(t)
+	for _, tt := range []struct{ config, want string }{
+		{config: "", want: "#"},
+		{config: ";", want: ";"},
+		{config: "auto", want: "#"},
+	} {
+		if tt.config != "" {
+			mustGit(t, "config", "core.commentChar", tt.config)
+		}
+		if got := GetCommentChar(); got != tt.want {
+			t.Errorf("GetCommentChar() with %q = %q, want %q", tt.config, got, tt.want)
+		}
+	}
+}
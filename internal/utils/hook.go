@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	HookPrepareCommitMsg = "prepare-commit-msg"
+	HookCommitMsg        = "commit-msg"
+
+	// hookMarker identifies hooks written by kommit
+	hookMarker = "# Installed by kommit."
+	// ChainedHookSuffix is appended to a pre-existing hook that kommit's hook
+	// runs before its own logic
+	ChainedHookSuffix = ".pre-kommit"
+
+	// HookSkipEnv is set when kommit itself runs `git commit`, so that its
+	// own hooks stay out of the way
+	HookSkipEnv = "KOMMIT_HOOK_SKIP"
+
+	// HookRejectExitCode is how `hook run commit-msg` rejects a message.
+	// Any other failure, such as a broken config or an older binary, lets
+	// the commit through.
+	HookRejectExitCode = 10
+)
+
+var SupportedHooks = []string{
+	HookPrepareCommitMsg,
+	HookCommitMsg,
+}
+
+func IsSupportedHook(name string) bool {
+	return slices.Contains(SupportedHooks, name)
+}
+
+// GetHooksDir returns the absolute path of the hooks directory, honouring
+// core.hooksPath.
+func GetHooksDir() (string, error) {
+	output, err := ExecGit("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(strings.TrimSpace(output))
+}
+
+func hookScript(name string, timeout time.Duration) string {
+	// Both hooks fail open, so commits from IDEs and GUIs never get stuck on
+	// kommit: prepare-commit-msg on any failure, commit-msg on anything but
+	// a rejected message, such as a crash
+	run := fmt.Sprintf(`git kommit hook run %s "$@"
+[ $? -eq %d ] && exit 1
+exit 0`, name, HookRejectExitCode)
+	if name == HookPrepareCommitMsg {
+		run = fmt.Sprintf(`git kommit hook run %s --timeout %s "$@" || true`, name, timeout)
+	}
+
+	return fmt.Sprintf(`#!/bin/sh
+%s Remove with `+"`git kommit hook uninstall %s`"+`.
+chained="$(dirname "$0")/%s%s"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+command -v git-kommit >/dev/null 2>&1 || exit 0
+%s
+`, hookMarker, name, name, ChainedHookSuffix, run)
+}
+
+type HookStatus struct {
+	Name      string
+	Path      string
+	Installed bool
+	Foreign   bool
+	Chained   bool
+}
+
+func GetHookStatus(name string) (HookStatus, error) {
+	hooksDir, err := GetHooksDir()
+	if err != nil {
+		return HookStatus{}, err
+	}
+
+	status := HookStatus{Name: name, Path: filepath.Join(hooksDir, name)}
+	if data, err := os.ReadFile(status.Path); err == nil {
+		status.Installed = bytes.Contains(data, []byte(hookMarker))
+		status.Foreign = !status.Installed
+	}
+	if _, err := os.Stat(status.Path + ChainedHookSuffix); err == nil {
+		status.Chained = true
+	}
+	return status, nil
+}
+
+// InstallHook writes kommit's hook. An existing hook that kommit didn't write
+// is kept and chained, so it still runs before kommit's.
+func InstallHook(name string, timeout time.Duration) (HookStatus, error) {
+	status, err := GetHookStatus(name)
+	if err != nil {
+		return status, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(status.Path), 0755); err != nil {
+		return status, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	if status.Foreign {
+		if status.Chained {
+			return status, fmt.Errorf("both %s and %s%s exist", name, name, ChainedHookSuffix)
+		}
+		if err := os.Rename(status.Path, status.Path+ChainedHookSuffix); err != nil {
+			return status, fmt.Errorf("failed to chain existing hook: %w", err)
+		}
+		status.Foreign = false
+		status.Chained = true
+	}
+
+	if err := WriteFileAtomic(status.Path, []byte(hookScript(name, timeout)), 0755); err != nil {
+		return status, err
+	}
+	status.Installed = true
+	return status, nil
+}
+
+// UninstallHook removes kommit's hook and restores any hook it chained.
+func UninstallHook(name string) (HookStatus, error) {
+	status, err := GetHookStatus(name)
+	if err != nil {
+		return status, err
+	}
+
+	if status.Foreign {
+		return status, fmt.Errorf("%s was not installed by kommit", name)
+	}
+
+	if status.Installed {
+		if err := os.Remove(status.Path); err != nil {
+			return status, fmt.Errorf("failed to remove hook: %w", err)
+		}
+		status.Installed = false
+	}
+
+	if status.Chained {
+		if err := os.Rename(status.Path+ChainedHookSuffix, status.Path); err != nil {
+			return status, fmt.Errorf("failed to restore chained hook: %w", err)
+		}
+		status.Chained = false
+		status.Foreign = true
+	}
+
+	return status, nil
+}
+
+// IsRebaseInProgress reports whether a rebase (interactive or not) is
+// currently stopped or replaying commits.
+func IsRebaseInProgress() bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		output, err := ExecGit("rev-parse", "--git-path", dir)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(strings.TrimSpace(output)); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
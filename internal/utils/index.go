@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// IndexBackup is a byte-for-byte copy of the index, so it can be put back
+// exactly as it was, including any partially staged files.
+type IndexBackup struct {
+	path string
+	data []byte
+	mode os.FileMode
+	// missing is set in a fresh repository, before anything was ever staged
+	missing bool
+}
+
+func BackupIndex() (*IndexBackup, error) {
+	path, err := ExecGit("rev-parse", "--git-path", "index")
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the index: %w", err)
+	}
+	path = strings.TrimSpace(path)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return &IndexBackup{path: path, missing: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	return &IndexBackup{path: path, data: data, mode: info.Mode().Perm()}, nil
+}
+
+func (b *IndexBackup) Restore() error {
+	if b.missing {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to restore the index: %w", err)
+		}
+		return nil
+	}
+	if err := WriteFileAtomic(b.path, b.data, b.mode); err != nil {
+		return fmt.Errorf("failed to restore the index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileLock is an advisory, exclusive lock held on a sidecar ".lock" file.
+// It only guards against other kommit processes, which all take the lock
+// before touching the files it protects.
+type FileLock struct {
+	file *os.File
+}
+
+// ErrLockTimeout is returned when another process holds a lock for longer
+// than the caller is willing to wait.
+var ErrLockTimeout = errors.New("timed out waiting for the lock")
+
+// How often a bounded wait retries a held lock
+const lockRetryInterval = 20 * time.Millisecond
+
+func LockFile(path string) (*FileLock, error) {
+	return LockFileWithin(path, 0)
+}
+
+// LockFileWithin is LockFile giving up with ErrLockTimeout after wait. A zero
+// wait blocks until the lock is free.
+func LockFileWithin(path string, wait time.Duration) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if wait <= 0 {
+		err = lockFile(file)
+	} else {
+		err = lockFileWithin(file, wait)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return &FileLock{file: file}, nil
+}
+
+func lockFileWithin(file *os.File, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil || locked {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (l *FileLock) Unlock() error {
+	defer l.file.Close()
+	return unlockFile(l.file)
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempFilePath := tempFile.Name()
+	defer os.Remove(tempFilePath) // No-op once renamed
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tempFilePath, perm); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+
+	if err := os.Rename(tempFilePath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFileWithin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+	held, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() error = %v", err)
+	}
+
+	start := time.Now()
+	if _, err := LockFileWithin(path, 50*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("LockFileWithin() on a held lock = %v, want ErrLockTimeout", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("LockFileWithin() waited %s", waited)
+	}
+
+	if err := held.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := LockFileWithin(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("LockFileWithin() on a free lock error = %v", err)
+	}
+	lock.Unlock()
+}
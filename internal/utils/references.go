@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+)
+
+const (
+	ReferencePlacementFooter  = "footer"
+	ReferencePlacementSubject = "subject"
+
+	DefaultReferenceToken = "Refs"
+)
+
+type referencePreset struct {
+	regex  *regexp.Regexp
+	format func(string) string
+}
+
+// Presets for common trackers. Jira keys are upper case in branch names,
+// while Linear suggests lower case ones like eng-123-fix-login. GitHub issue
+// numbers start a path segment, as in fix/123-login or gh-123.
+var referencePresets = map[string]referencePreset{
+	"jira": {
+		regex:  regexp.MustCompile(`\b([A-Z][A-Z0-9]+-\d+)\b`),
+		format: func(s string) string { return s },
+	},
+	"linear": {
+		regex:  regexp.MustCompile(`(?i)(?:^|/)([a-z][a-z0-9]*-\d+)(?:\b|$)`),
+		format: strings.ToUpper,
+	},
+	"github": {
+		regex:  regexp.MustCompile(`(?i)(?:^|/)(?:#|gh-|issue-)?(\d+)(?:[/_-]|$)`),
+		format: func(s string) string { return "#" + s },
+	},
+}
+
+// GetBranchReferences extracts ticket references from the current branch
+// name. Each pattern contributes its first capture group, or the whole match
+// if it has none.
+func GetBranchReferences(config ReferencesConfig) ([]string, error) {
+	if len(config.Patterns) == 0 {
+		return nil, nil
+	}
+	branch := GetBranchName()
+	if branch == "" {
+		return nil, nil
+	}
+	return FindReferences(branch, config.Patterns)
+}
+
+func FindReferences(branch string, patterns []string) ([]string, error) {
+	var references []string
+	for _, pattern := range patterns {
+		preset, ok := referencePresets[pattern]
+		if !ok {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid reference pattern %q: %w", pattern, err)
+			}
+			preset = referencePreset{regex: regex, format: func(s string) string { return s }}
+		}
+
+		for _, match := range preset.regex.FindAllStringSubmatch(branch, -1) {
+			reference := match[0]
+			if len(match) > 1 {
+				reference = match[1]
+			}
+			reference = preset.format(reference)
+			if reference != "" && !slices.Contains(references, reference) {
+				references = append(references, reference)
+			}
+		}
+	}
+	return references, nil
+}
+
+// AddReferences puts the references in a footer (e.g. "Refs: PAY-1234") or at
+// the start of the subject's description, skipping those the message
+// already mentions. References that would push the subject past
+// subjectMaxLength (if set) go in the footer instead.
+func AddReferences(config ReferencesConfig, message string, references []string, subjectMaxLength int) (string, error) {
+	var missing []string
+	for _, reference := range references {
+		if !mentions(message, reference) {
+			missing = append(missing, reference)
+		}
+	}
+	if len(missing) == 0 {
+		return message, nil
+	}
+
+	switch config.Placement {
+	case "", ReferencePlacementFooter:
+		return addReferenceFooter(config, message, missing)
+	case ReferencePlacementSubject:
+		subject, body, _ := strings.Cut(message, "\n")
+		prefix := strings.Join(missing, " ")
+		if header, err := conventional.ParseHeader(subject); err == nil {
+			header.Description = prefix + " " + header.Description
+			subject = header.String()
+		} else {
+			subject = prefix + " " + subject
+		}
+		if subjectMaxLength > 0 && utf8.RuneCountInString(subject) > subjectMaxLength {
+			return addReferenceFooter(config, message, missing)
+		}
+		if body == "" {
+			return subject, nil
+		}
+		return subject + "\n" + body, nil
+	default:
+		return "", fmt.Errorf("unknown reference placement %q", config.Placement)
+	}
+}
+
+func addReferenceFooter(config ReferencesConfig, message string, references []string) (string, error) {
+	token := config.Token
+	if token == "" {
+		token = DefaultReferenceToken
+	}
+	return AddTrailers(message, []string{token + ": " + strings.Join(references, ", ")})
+}
+
+// mentions reports whether the message contains the reference as a whole
+// word, so that #123 doesn't count as a mention of #12.
+func mentions(message, reference string) bool {
+	regex := regexp.MustCompile(`(?:^|[^\w-])` + regexp.QuoteMeta(reference) + `(?:[^\w-]|$)`)
+	return regex.MatchString(message)
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindReferences(t *testing.T) {
+	tests := []struct {
+		branch   string
+		patterns []string
+		want     []string
+	}{
+		{branch: "feature/PAY-1234-refunds", patterns: []string{"jira"}, want: []string{"PAY-1234"}},
+		{branch: "PAY-1-and-OPS-22", patterns: []string{"jira"}, want: []string{"PAY-1", "OPS-22"}},
+		{branch: "eng-123-fix-login", patterns: []string{"linear"}, want: []string{"ENG-123"}},
+		{branch: "fix/123-login", patterns: []string{"github"}, want: []string{"#123"}},
+		{branch: "gh-42", patterns: []string{"github"}, want: []string{"#42"}},
+		{branch: "release-2024", patterns: []string{"github"}},
+		{branch: "main", patterns: []string{"jira", "github"}},
+		{branch: "story/sc-77", patterns: []string{`sc-(\d+)`}, want: []string{"77"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got, err := FindReferences(tt.branch, tt.patterns)
+			if err != nil {
+				t.Fatalf("FindReferences() error = %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("FindReferences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FindReferences("main", []string{"("}); err == nil {
+		t.Error("FindReferences() with an invalid pattern should fail")
+	}
+}
+
+func TestAddReferences(t *testing.T) {
+	setTestGitEnv(t)
+	footer := ReferencesConfig{}
+	subject := ReferencesConfig{Placement: ReferencePlacementSubject}
+
+	tests := []struct {
+		name             string
+		config           ReferencesConfig
+		message          string
+		references       []string
+		subjectMaxLength int
+		want             string
+	}{
+		{
+			name:       "footer",
+			config:     footer,
+			message:    "fix: handle nil\n\nExplain.",
+			references: []string{"PAY-1", "#2"},
+			want:       "fix: handle nil\n\nExplain.\n\nRefs: PAY-1, #2",
+		},
+		{
+			name:       "custom token",
+			config:     ReferencesConfig{Token: "Jira"},
+			message:    "fix: handle nil",
+			references: []string{"PAY-1"},
+			want:       "fix: handle nil\n\nJira: PAY-1",
+		},
+		{
+			name:       "already mentioned",
+			config:     footer,
+			message:    "fix: handle nil\n\nCloses #12",
+			references: []string{"#12"},
+			want:       "fix: handle nil\n\nCloses #12",
+		},
+		{
+			name:       "longer reference mentioned",
+			config:     footer,
+			message:    "fix: handle nil\n\nRefs: #123",
+			references: []string{"#12"},
+			want:       "fix: handle nil\n\nRefs: #123\nRefs: #12",
+		},
+		{
+			name:       "longer ticket mentioned",
+			config:     footer,
+			message:    "fix: handle nil (PAY-123)",
+			references: []string{"PAY-12"},
+			want:       "fix: handle nil (PAY-123)\n\nRefs: PAY-12",
+		},
+		{
+			name:       "subject",
+			config:     subject,
+			message:    "fix(api): handle nil\n\nExplain.",
+			references: []string{"PAY-1"},
+			want:       "fix(api): PAY-1 handle nil\n\nExplain.",
+		},
+		{
+			name:       "subject that isn't conventional",
+			config:     subject,
+			message:    "Handle nil",
+			references: []string{"PAY-1"},
+			want:       "PAY-1 Handle nil",
+		},
+		{
+			name:             "subject within the limit",
+			config:           subject,
+			message:          "fix: handle nil",
+			references:       []string{"PAY-1"},
+			subjectMaxLength: 21,
+			want:             "fix: PAY-1 handle nil",
+		},
+		{
+			name:             "subject past the limit",
+			config:           subject,
+			message:          "fix: handle nil",
+			references:       []string{"PAY-1"},
+			subjectMaxLength: 20,
+			want:             "fix: handle nil\n\nRefs: PAY-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddReferences(tt.config, tt.message, tt.references, tt.subjectMaxLength)
+			if err != nil {
+				t.Fatalf("AddReferences() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AddReferences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := AddReferences(ReferencesConfig{Placement: "body"}, "fix: x", []string{"PAY-1"}, 0); err == nil {
+		t.Error("AddReferences() with an unknown placement should fail")
+	}
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const backupRefPrefix = "refs/kommit/backup/"
+
+// CommitInfo holds everything needed to recreate a commit with git
+// commit-tree.
+type CommitInfo struct {
+	Hash           string
+	Tree           string
+	Parents        []string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorDate     string
+	CommitterName  string
+	CommitterEmail string
+	CommitterDate  string
+	Message        string
+}
+
+func (c CommitInfo) ShortHash() string {
+	return c.Hash[:min(len(c.Hash), 7)]
+}
+
+func (c CommitInfo) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+func (c CommitInfo) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+func GetCommitInfo(rev string) (CommitInfo, error) {
+	format := strings.Join([]string{"%H", "%T", "%P", "%an", "%ae", "%ad", "%cn", "%ce", "%cd", "%B"}, "%x00")
+	output, err := ExecGit("log", "-1", "--date=raw", "--format="+format, rev, "--")
+	if err != nil {
+		return CommitInfo{}, fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+
+	fields := strings.SplitN(output, "\x00", 10)
+	if len(fields) != 10 {
+		return CommitInfo{}, fmt.Errorf("failed to parse commit %s", rev)
+	}
+
+	return CommitInfo{
+		Hash:           fields[0],
+		Tree:           fields[1],
+		Parents:        strings.Fields(fields[2]),
+		AuthorName:     fields[3],
+		AuthorEmail:    fields[4],
+		AuthorDate:     fields[5],
+		CommitterName:  fields[6],
+		CommitterEmail: fields[7],
+		CommitterDate:  fields[8],
+		Message:        strings.TrimRight(fields[9], "\n"),
+	}, nil
+}
+
+// ListCommits returns the commits in a revision range, parents before
+// children.
+func ListCommits(revisionRange ...string) ([]string, error) {
+	args := append([]string{"rev-list", "--reverse", "--topo-order"}, revisionRange...)
+	output, err := ExecGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	return strings.Fields(output), nil
+}
+
+// GetDiff returns the changes a commit introduced relative to its first
+// parent.
+func GetDiff(commit CommitInfo) (string, error) {
+	parent := EmptyTreeHash
+	if len(commit.Parents) > 0 {
+		parent = commit.Parents[0]
+	}
+	return ExecGit("diff", parent, commit.Hash)
+}
+
+// GetTrailers returns the trailer lines (e.g. "Signed-off-by: ...") of a
+// message.
+func GetTrailers(message string) ([]string, error) {
+	output, err := ExecGitWithInput(message+"\n", nil, "interpret-trailers", "--parse", "--no-divider")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trailers: %w", err)
+	}
+
+	var trailers []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			trailers = append(trailers, line)
+		}
+	}
+	return trailers, nil
+}
+
+// PreserveTrailers appends the trailers of the old message to the new one,
+// skipping any the new message already carries.
+func PreserveTrailers(oldMessage, newMessage string) (string, error) {
+	trailers, err := GetTrailers(oldMessage)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for _, trailer := range trailers {
+		if !strings.Contains(newMessage, trailer) {
+			missing = append(missing, trailer)
+		}
+	}
+	if len(missing) == 0 {
+		return newMessage, nil
+	}
+	return AddTrailers(newMessage, missing)
+}
+
+// CommitTree recreates a commit with a new message and parents, keeping its
+// tree, authorship and dates.
+func CommitTree(commit CommitInfo, parents []string, message string) (string, error) {
+	args := []string{"commit-tree", commit.Tree}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", "-")
+
+	env := []string{
+		"GIT_AUTHOR_NAME=" + commit.AuthorName,
+		"GIT_AUTHOR_EMAIL=" + commit.AuthorEmail,
+		"GIT_AUTHOR_DATE=" + commit.AuthorDate,
+		"GIT_COMMITTER_NAME=" + commit.CommitterName,
+		"GIT_COMMITTER_EMAIL=" + commit.CommitterEmail,
+		"GIT_COMMITTER_DATE=" + commit.CommitterDate,
+	}
+
+	output, err := ExecGitWithInput(message+"\n", env, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite commit %s: %w", commit.ShortHash(), err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// CreateBackupRef points a new ref under refs/kommit/backup/ at rev, so that
+// a history rewrite can be undone.
+func CreateBackupRef(kind, rev string) (string, error) {
+	ref := fmt.Sprintf("%s%s-%s", backupRefPrefix, kind, time.Now().Format("20060102-150405"))
+	if _, err := ExecGit("update-ref", "-m", "kommit: backup before "+kind, ref, rev, ""); err != nil {
+		return "", fmt.Errorf("failed to create backup ref: %w", err)
+	}
+	return ref, nil
+}
+
+// UpdateHead moves the current branch (or a detached HEAD) from oldHead to
+// newHead without touching the index or working tree. It fails if HEAD moved
+// in the meantime.
+func UpdateHead(oldHead, newHead, reason string) error {
+	ref := "HEAD"
+	if branch, err := ExecGit("symbolic-ref", "-q", "HEAD"); err == nil {
+		ref = strings.TrimSpace(branch)
+	}
+
+	if _, err := ExecGit("update-ref", "-m", "kommit: "+reason, ref, newHead, oldHead); err != nil {
+		return fmt.Errorf("failed to update %s: %w", ref, err)
+	}
+	return nil
+}
+
+// RewriteMessages recreates every commit from the oldest rewritten one up to
+// HEAD, replacing the messages given by hash. Trees, authorship and dates are
+// kept, so descendants only change because their parents do. It returns the
+// new HEAD.
+func RewriteMessages(head string, messages map[string]string) (string, error) {
+	// Boundaries: parents of rewritten commits that don't descend from
+	// another rewritten commit, so untouched commits in between are rewritten
+	// too rather than cutting off everything before them
+	var boundaries []string
+	for hash := range messages {
+		commit, err := GetCommitInfo(hash)
+		if err != nil {
+			return "", err
+		}
+		for _, parent := range commit.Parents {
+			if !descendsFromAny(parent, messages) {
+				boundaries = append(boundaries, "^"+parent)
+			}
+		}
+	}
+
+	hashes, err := ListCommits(append([]string{head}, boundaries...)...)
+	if err != nil {
+		return "", err
+	}
+
+	rewritten := make(map[string]string)
+	newHead := head
+	for _, hash := range hashes {
+		commit, err := GetCommitInfo(hash)
+		if err != nil {
+			return "", err
+		}
+
+		parents := make([]string, len(commit.Parents))
+		for i, parent := range commit.Parents {
+			parents[i] = parent
+			if newParent, ok := rewritten[parent]; ok {
+				parents[i] = newParent
+			}
+		}
+
+		message := commit.Message
+		if newMessage, ok := messages[hash]; ok {
+			message = newMessage
+		}
+
+		newHash, err := CommitTree(commit, parents, message)
+		if err != nil {
+			return "", err
+		}
+		rewritten[hash] = newHash
+		newHead = newHash
+	}
+
+	for hash := range messages {
+		if _, ok := rewritten[hash]; !ok {
+			return "", fmt.Errorf("commit %.7s is not an ancestor of %.7s", hash, head)
+		}
+	}
+
+	return newHead, nil
+}
+
+// descendsFromAny reports whether rev is one of the commits or a descendant
+// of one.
+func descendsFromAny(rev string, commits map[string]string) bool {
+	for hash := range commits {
+		if _, err := ExecGit("merge-base", "--is-ancestor", hash, rev); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates an empty repository in a temporary directory and makes
+// it the working directory for the rest of the test.
+func newTestRepo(t *testing.T) {
+	t.Helper()
+	setTestGitEnv(t)
+	t.Chdir(t.TempDir())
+	mustGit(t, "init", "-q", "-b", "main")
+}
+
+// setTestGitEnv isolates git from the user's configuration and gives it an
+// identity to commit with.
+func setTestGitEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "Kommit Test")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "test@kommit.dev")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func mustGit(t *testing.T, args ...string) string {
+	t.Helper()
+	output, err := ExecGit(args...)
+	if err != nil {
+		t.Fatalf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(output)
+}
+
+// commitFile writes a file and commits it, returning the commit's hash.
+func commitFile(t *testing.T, name, content, message string) string {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(t, "add", name)
+	mustGit(t, "commit", "-q", "-m", message)
+	return mustGit(t, "rev-parse", "HEAD")
+}
+
+func TestRewriteMessages(t *testing.T) {
+	tests := []struct {
+		name   string
+		reword []int
+		want   []string
+	}{
+		{
+			name:   "last commit",
+			reword: []int{3},
+			want:   []string{"root", "wip a", "wip b", "new 3"},
+		},
+		{
+			name:   "adjacent commits",
+			reword: []int{1, 2},
+			want:   []string{"root", "new 1", "new 2", "wip c"},
+		},
+		{
+			name:   "untouched commit in between",
+			reword: []int{1, 3},
+			want:   []string{"root", "new 1", "wip b", "new 3"},
+		},
+		{
+			name:   "root commit",
+			reword: []int{0, 2},
+			want:   []string{"new 0", "wip a", "new 2", "wip c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRepo(t)
+			hashes := []string{
+				commitFile(t, "root.txt", "root\n", "root"),
+				commitFile(t, "a.txt", "a\n", "wip a"),
+				commitFile(t, "b.txt", "b\n", "wip b"),
+				commitFile(t, "c.txt", "c\n", "wip c"),
+			}
+			head := hashes[len(hashes)-1]
+
+			messages := make(map[string]string)
+			for _, i := range tt.reword {
+				messages[hashes[i]] = "new " + string(rune('0'+i))
+			}
+
+			newHead, err := RewriteMessages(head, messages)
+			if err != nil {
+				t.Fatalf("RewriteMessages() error = %v", err)
+			}
+
+			got := strings.Split(mustGit(t, "log", "--reverse", "--format=%s", newHead), "\n")
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+			if tree := mustGit(t, "rev-parse", newHead+"^{tree}"); tree != mustGit(t, "rev-parse", head+"^{tree}") {
+				t.Errorf("tree changed to %s", tree)
+			}
+		})
+	}
+}
+
+func TestRewriteMessagesNotAncestor(t *testing.T) {
+	newTestRepo(t)
+	commitFile(t, "root.txt", "root\n", "root")
+	head := commitFile(t, "a.txt", "a\n", "a")
+	mustGit(t, "checkout", "-q", "-b", "other", "HEAD^")
+	other := commitFile(t, "b.txt", "b\n", "b")
+
+	if _, err := RewriteMessages(head, map[string]string{other: "new b"}); err == nil {
+		t.Error("RewriteMessages() with a commit off the branch should fail")
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
 )
 
 func GetScopesFromHistory() ([]string, error) {
@@ -15,7 +16,6 @@ func GetScopesFromHistory() ([]string, error) {
 		return nil, err
 	}
 
-	scopeRegex := regexp.MustCompile(`^[\w]+\(([\w-]+)\)(?:!)?:`)
 	scopesMap := make(map[string]bool)
 	commitLines := strings.Split(strings.TrimSpace(output), "\n")
 	for _, line := range commitLines {
@@ -23,9 +23,9 @@ func GetScopesFromHistory() ([]string, error) {
 			continue
 		}
 
-		matches := scopeRegex.FindStringSubmatch(line)
-		if len(matches) > 1 && matches[1] != "" {
-			scopesMap[matches[1]] = true
+		header, err := conventional.ParseHeader(line)
+		if err == nil && header.Scope != "" {
+			scopesMap[header.Scope] = true
 		}
 	}
 
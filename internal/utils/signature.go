@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cowboy-bebug/kommit/internal/conventional"
+)
+
+const (
+	SignatureStyleText    = "text"
+	SignatureStyleTrailer = "trailer"
+	SignatureStyleOff     = "off"
+
+	DefaultSignatureText    = "[Generated by Kommit]"
+	DefaultSignatureTrailer = "Generated-by: kommit {model}"
+)
+
+// SignMessage adds kommit's attribution and the repo's static trailers to a
+// generated message. Trailers may use the {model}, {name} and {email}
+// placeholders, the latter two taken from git's user.name and user.email.
+func SignMessage(config CommitConfig, message, model string) (string, error) {
+	var trailers []string
+
+	switch config.Signature.Style {
+	case "", SignatureStyleText:
+		text := config.Signature.Text
+		if text == "" {
+			text = DefaultSignatureText
+		}
+		// Footers have to stay last, so the text goes above them
+		if parsed, err := conventional.Parse(message); err == nil && len(parsed.Footers) > 0 {
+			parsed.Body = strings.TrimLeft(parsed.Body+"\n\n"+text, "\n")
+			message = parsed.String()
+		} else {
+			message = fmt.Sprintf("%s\n\n%s", message, text)
+		}
+	case SignatureStyleTrailer:
+		trailer := config.Signature.Trailer
+		if trailer == "" {
+			trailer = DefaultSignatureTrailer
+		}
+		trailers = append(trailers, trailer)
+	case SignatureStyleOff:
+	default:
+		return "", fmt.Errorf("unknown signature style %q", config.Signature.Style)
+	}
+
+	trailers = append(trailers, config.Trailers...)
+	if len(trailers) == 0 {
+		return message, nil
+	}
+
+	replacer := strings.NewReplacer(
+		"{model}", model,
+		"{name}", gitConfigValue("user.name"),
+		"{email}", gitConfigValue("user.email"),
+	)
+	for i, trailer := range trailers {
+		trailers[i] = replacer.Replace(trailer)
+	}
+
+	return AddTrailers(message, trailers)
+}
+
+// AddTrailers appends trailers with `git interpret-trailers`, so they join
+// any existing trailer block and identical ones aren't repeated.
+func AddTrailers(message string, trailers []string) (string, error) {
+	// git doesn't take "BREAKING CHANGE: ..." for a trailer and would start a
+	// new paragraph after it, splitting the footers
+	if parsed, err := conventional.Parse(message); err == nil && hasBreakingChangeFooter(parsed) {
+		return addFooters(parsed, trailers), nil
+	}
+
+	args := []string{"interpret-trailers", "--if-exists", "addIfDifferent"}
+	for _, trailer := range trailers {
+		args = append(args, "--trailer", trailer)
+	}
+
+	output, err := ExecGitWithInput(message+"\n", nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to add trailers: %w", err)
+	}
+	return strings.TrimRight(output, "\n"), nil
+}
+
+func gitConfigValue(key string) string {
+	value, err := ExecGit("config", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+func hasBreakingChangeFooter(message *conventional.Message) bool {
+	for _, footer := range message.Footers {
+		if footer.IsBreakingChange() {
+			return true
+		}
+	}
+	return false
+}
+
+func addFooters(message *conventional.Message, trailers []string) string {
+	for _, trailer := range trailers {
+		token, value, _ := strings.Cut(trailer, ":")
+		message.AddFooter(strings.TrimSpace(token), strings.TrimSpace(value))
+	}
+	return message.String()
+}